@@ -0,0 +1,34 @@
+package scheduled
+
+import "time"
+
+// ArchivedTask is a done task that was removed from the board together with
+// the time it was completed at.
+type ArchivedTask struct {
+	Task      Task      `json:"task"`
+	Completed time.Time `json:"completed"`
+}
+
+// Archive archives the done task as completed at the time it was checked.
+// Tasks checked before that time was recorded are archived as completed now.
+// Times are stored with a precision of seconds.
+func Archive(t Task, now time.Time) ArchivedTask {
+	completed := t.Completed
+	if completed.IsZero() {
+		completed = now
+	}
+	return ArchivedTask{Task: t, Completed: completed.Truncate(time.Second)}
+}
+
+// Restore returns the task as a new task of the Inbox, which is neither
+// done, pinned nor recurring.
+func (a ArchivedTask) Restore() Task {
+	t := a.Task
+	t.Day = 0
+	t.Done = false
+	t.DoneWeeks = nil
+	t.Completed = time.Time{}
+	t.Recurrence = nil
+	t.Unpin()
+	return t
+}
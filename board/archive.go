@@ -0,0 +1,81 @@
+package board
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+// AllContexts selects the archived tasks of all contexts.
+const AllContexts = 0
+
+// Archive returns all archived tasks in the order they were archived.
+func (m *Model) Archive() []scheduled.ArchivedTask {
+	return slices.Clone(m.archive)
+}
+
+// SetArchive replaces the archived tasks.
+func (m *Model) SetArchive(archive []scheduled.ArchivedTask) {
+	m.archive = slices.Clone(archive)
+}
+
+// LoadArchive returns the archived tasks of the repository without changing
+// the board.
+func (m *Model) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	return m.repository.LoadArchive()
+}
+
+func (m *Model) loadArchive() error {
+	archive, err := m.repository.LoadArchive()
+	if err != nil {
+		return err
+	}
+	m.archive = archive
+	return nil
+}
+
+func (m *Model) saveArchive() error {
+	return m.repository.SaveArchive(m.archive)
+}
+
+// archiveTask adds the task to the archive as completed at the time it was
+// checked.
+func (m *Model) archiveTask(t scheduled.Task) {
+	m.archive = append(m.archive, scheduled.Archive(t, m.clock.Now()))
+}
+
+// ArchivedTasks returns the tasks archived in the given ISO week that belong
+// to the context with the given ID, or to any context for AllContexts.
+func (m *Model) ArchivedTasks(year, week, contextID int) []scheduled.ArchivedTask {
+	var result []scheduled.ArchivedTask
+	for _, a := range m.archive {
+		y, w := a.Completed.ISOWeek()
+		if y != year || w != week {
+			continue
+		}
+		if contextID != AllContexts && a.Task.Context != contextID {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+// RestoreArchived removes the task with the given ID from the archive and
+// adds it to the end of the Inbox as a new task.
+func (m *Model) RestoreArchived(id string) error {
+	i := slices.IndexFunc(m.archive, func(a scheduled.ArchivedTask) bool {
+		return a.Task.ID == id
+	})
+	if i < 0 {
+		return errors.New("task is not archived")
+	}
+	t := m.archive[i].Restore()
+	m.archive = slices.Delete(slices.Clone(m.archive), i, i+1)
+
+	tasks := m.flattenTasks()
+	t.Pos = len(m.lists[Inbox].allTasks())
+	m.distribute(append(tasks, t))
+	return nil
+}
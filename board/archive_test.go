@@ -0,0 +1,97 @@
+package board
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+)
+
+func TestModel_DeleteTaskArchives(t *testing.T) {
+	repo := &mockRepository{tasks: []scheduled.Task{
+		{ID: "done", Name: "Done", Day: Inbox, Done: true, Context: 2},
+		{ID: "open", Name: "Open", Day: Inbox, Pos: 1},
+	}}
+	m := newTestModel(t, repo)
+
+	m.DeleteTask(Inbox)
+	m.lists[Inbox].Select(0)
+	m.DeleteTask(Inbox)
+	if err := m.SaveTasks(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(repo.tasks) != 1 || len(repo.archive) != 1 {
+		t.Fatalf("tasks = %v, archive = %v, want the done task archived", repo.tasks, repo.archive)
+	}
+	if a := repo.archive[0]; a.Task.ID != "done" || !a.Completed.Equal(time.Time(testClock)) {
+		t.Errorf("archived %+v, want done completed at %v", a, time.Time(testClock))
+	}
+
+	tests := []struct {
+		name      string
+		year      int
+		week      int
+		contextID int
+		want      int
+	}{
+		{"all contexts", 2026, 10, AllContexts, 1},
+		{"context", 2026, 10, 2, 1},
+		{"other context", 2026, 10, 3, 0},
+		{"other week", 2026, 11, AllContexts, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.ArchivedTasks(tt.year, tt.week, tt.contextID); len(got) != tt.want {
+				t.Errorf("ArchivedTasks() = %v, want %d tasks", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel_DeleteTaskArchivesCompletionTime(t *testing.T) {
+	checked := time.Date(2026, time.March, 2, 18, 15, 0, 0, time.Local)
+	repo := &mockRepository{tasks: []scheduled.Task{{ID: "rolling", Name: "Rolling", Day: Monday}}}
+	m, err := NewModel(repo, date.Fixed(checked))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.lists[Monday].Select(0)
+	m.ToggleDone(Monday)
+	if got := m.GetTasksForPanel(Monday)[0].Completed; !got.Equal(checked) {
+		t.Fatalf("Completed = %v, want %v", got, checked)
+	}
+
+	// Deleted two days later, the task is still archived as completed on
+	// Monday
+	m.clock = testClock
+	m.DeleteTask(Monday)
+	if a := m.Archive(); len(a) != 1 || !a[0].Completed.Equal(checked) {
+		t.Errorf("archive = %+v, want the task completed at %v", a, checked)
+	}
+}
+
+func TestModel_RestoreArchived(t *testing.T) {
+	pinned := scheduled.Task{ID: "pinned", Name: "Pinned", Day: Friday, Date: "2026-03-06", Done: true, Pos: 3}
+	repo := &mockRepository{
+		tasks:   []scheduled.Task{{ID: "inbox", Name: "Inbox"}},
+		archive: []scheduled.ArchivedTask{scheduled.Archive(pinned, time.Time(testClock))},
+	}
+	m := newTestModel(t, repo)
+
+	if err := m.RestoreArchived("pinned"); err != nil {
+		t.Fatalf("RestoreArchived() error = %v", err)
+	}
+	tasks := m.GetTasksForPanel(Inbox)
+	if len(tasks) != 2 || tasks[1].ID != "pinned" || tasks[1].Done || tasks[1].Pinned() {
+		t.Errorf("Inbox = %+v, want the task restored as open task", tasks)
+	}
+	if len(m.Archive()) != 0 || len(repo.archive) != 1 {
+		t.Errorf("archive = %v, want the task removed without changing the loaded archive", m.Archive())
+	}
+
+	if err := m.RestoreArchived("pinned"); err == nil {
+		t.Error("RestoreArchived() should fail for tasks that are not archived")
+	}
+}
@@ -1,6 +1,8 @@
 package board
 
 import (
+	"time"
+
 	"github.com/charmbracelet/bubbles/list"
 	"github.com/rwirdemann/scheduled"
 )
@@ -11,11 +13,15 @@ type ListModel struct {
 	savedIndex int
 	context    scheduled.Context
 	allItems   []list.Item
+	date       time.Time
+
+	// marked holds the IDs of the marked tasks.
+	marked map[string]bool
 }
 
 // NewListModel creates and returns a new instance of ListModel.
 func NewListModel(l list.Model) *ListModel {
-	return &ListModel{Model: l, savedIndex: 0, context: scheduled.ContextNone}
+	return &ListModel{Model: l, savedIndex: 0, context: scheduled.ContextNone, marked: make(map[string]bool)}
 }
 
 // SaveIndex saves the current index of the list model.
@@ -84,6 +90,69 @@ func (lm *ListModel) SetContext(context scheduled.Context) {
 	lm.context = context
 }
 
+// SetTasks replaces all tasks of the list model while keeping the current
+// context filter and selection.
+func (lm *ListModel) SetTasks(tasks []scheduled.Task) {
+	items := make([]list.Item, len(tasks))
+	for i, t := range tasks {
+		items[i] = t
+	}
+
+	if lm.context == scheduled.ContextNone {
+		lm.allItems = nil
+	} else {
+		lm.allItems = items
+		var filtered []list.Item
+		for _, item := range items {
+			if item.(scheduled.Task).Context == lm.context.ID {
+				filtered = append(filtered, item)
+			}
+		}
+		items = filtered
+	}
+
+	index := lm.Index()
+	lm.SetItems(items)
+	if index >= len(items) {
+		lm.Select(max(len(items)-1, 0))
+	}
+}
+
+// SetDate sets the date the list model shows tasks for. Done states of rolling
+// tasks are toggled for the week of this date.
+func (lm *ListModel) SetDate(date time.Time) {
+	lm.date = date
+}
+
+// replaceTask replaces the task with the same ID as the given task.
+func (lm *ListModel) replaceTask(t scheduled.Task) {
+	for i, item := range lm.Items() {
+		if item.(scheduled.Task).ID == t.ID {
+			lm.SetItem(i, t)
+		}
+	}
+	for i, item := range lm.allItems {
+		if item.(scheduled.Task).ID == t.ID {
+			lm.allItems[i] = t
+		}
+	}
+}
+
+// removeTask removes the task with the given ID.
+func (lm *ListModel) removeTask(id string) {
+	items := lm.Items()
+	for i := len(items) - 1; i >= 0; i-- {
+		if items[i].(scheduled.Task).ID == id {
+			lm.RemoveItem(i)
+		}
+	}
+	for i := len(lm.allItems) - 1; i >= 0; i-- {
+		if lm.allItems[i].(scheduled.Task).ID == id {
+			lm.allItems = append(lm.allItems[:i], lm.allItems[i+1:]...)
+		}
+	}
+}
+
 // Deselect clears the selection in the list model.
 func (lm *ListModel) Deselect() {
 	lm.Select(-1)
@@ -121,15 +190,16 @@ func (lm *ListModel) MoveItemDown() bool {
 	return true
 }
 
-// ToggleDone toggles the done state of the selected task.
-func (lm *ListModel) ToggleDone() bool {
+// ToggleDone toggles the done state of the selected task. Checked tasks are
+// completed at now.
+func (lm *ListModel) ToggleDone(now time.Time) bool {
 	selected := lm.SelectedItem()
 	if selected == nil {
 		return false
 	}
 	oldTask := selected.(scheduled.Task)
 	t := oldTask
-	t.Done = !t.Done
+	t.Check(lm.date, !t.Done, now)
 	idx := lm.Index()
 	lm.RemoveItem(idx)
 	lm.InsertItem(idx, t)
@@ -97,7 +97,7 @@ func TestListModel_ToggleDone(t *testing.T) {
 	}
 
 	// Toggle to done
-	toggled := lm.ToggleDone()
+	toggled := lm.ToggleDone(testClock.Now())
 	if !toggled {
 		t.Error("ToggleDone() should return true")
 	}
@@ -108,7 +108,7 @@ func TestListModel_ToggleDone(t *testing.T) {
 	}
 
 	// Toggle back to not done
-	lm.ToggleDone()
+	lm.ToggleDone(testClock.Now())
 	afterSecondToggle := lm.SelectedItem().(scheduled.Task)
 	if afterSecondToggle.Done {
 		t.Error("Task should be marked as not done after second toggle")
@@ -0,0 +1,212 @@
+package board
+
+import (
+	"io"
+	"slices"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/rwirdemann/scheduled"
+)
+
+var markStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("214"))
+
+// markDelegate renders tasks like the default delegate and highlights marked
+// tasks.
+type markDelegate struct {
+	list.DefaultDelegate
+	marked map[string]bool
+}
+
+func (d markDelegate) Render(w io.Writer, m list.Model, index int, item list.Item) {
+	t, ok := item.(scheduled.Task)
+	if !ok || !d.marked[t.ID] {
+		d.DefaultDelegate.Render(w, m, index, item)
+		return
+	}
+	marked := d.DefaultDelegate
+	marked.Styles.NormalTitle = marked.Styles.NormalTitle.Inherit(markStyle)
+	marked.Styles.SelectedTitle = marked.Styles.SelectedTitle.Inherit(markStyle)
+	t.Name = "» " + t.Name
+	marked.Render(w, m, index, t)
+}
+
+// setDelegate sets the delegate that renders the tasks of the list.
+func (lm *ListModel) setDelegate(showDescription bool) {
+	lm.SetDelegate(markDelegate{DefaultDelegate: newDelegate(showDescription), marked: lm.marked})
+}
+
+// ToggleMark marks the selected task or removes its mark and selects the next
+// task, so that several tasks can be marked in a row.
+func (lm *ListModel) ToggleMark() {
+	item := lm.SelectedItem()
+	if item == nil {
+		return
+	}
+	id := item.(scheduled.Task).ID
+	if lm.marked[id] {
+		delete(lm.marked, id)
+	} else {
+		lm.marked[id] = true
+	}
+	lm.CursorDown()
+}
+
+// markedTasks returns the shown tasks that are marked in list order.
+func (lm *ListModel) markedTasks() []scheduled.Task {
+	var tasks []scheduled.Task
+	for _, item := range lm.Items() {
+		if t := item.(scheduled.Task); lm.marked[t.ID] {
+			tasks = append(tasks, t)
+		}
+	}
+	return tasks
+}
+
+// ToggleMark marks the selected task in the list at the given index or
+// removes its mark. Marks span all lists of the week and are cleared when the
+// week or the context changes.
+func (m *Model) ToggleMark(listIndex int) {
+	if l, exists := m.lists[listIndex]; exists {
+		l.ToggleMark()
+	}
+}
+
+// ClearMarks removes the marks of all tasks.
+func (m *Model) ClearMarks() {
+	for _, l := range m.lists {
+		clear(l.marked)
+	}
+}
+
+// HasMarks returns true if at least one task is marked.
+func (m *Model) HasMarks() bool {
+	for _, l := range m.lists {
+		if len(l.markedTasks()) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkedTasks returns the marked tasks from the Inbox to Sunday with the day
+// of the list they are marked in. Tasks marked on several days are returned
+// once.
+func (m *Model) MarkedTasks() []scheduled.Task {
+	var tasks []scheduled.Task
+	seen := make(map[string]bool)
+	for i := Inbox; i <= Sunday; i++ {
+		for _, t := range m.lists[i].markedTasks() {
+			if !seen[t.ID] {
+				seen[t.ID] = true
+				t.Day = i
+				tasks = append(tasks, t)
+			}
+		}
+	}
+	return tasks
+}
+
+// mark is a marked task in the list at the given index.
+type mark struct {
+	list int
+	id   string
+}
+
+// eachMarked selects each marked task in turn and calls f with the index of
+// its list. The selections of all lists are restored afterwards.
+func (m *Model) eachMarked(f func(listIndex int)) {
+	var marks []mark
+	selected := make(map[int]int)
+	for i := Inbox; i <= Sunday; i++ {
+		selected[i] = m.lists[i].Index()
+		for _, t := range m.lists[i].markedTasks() {
+			marks = append(marks, mark{i, t.ID})
+		}
+	}
+
+	for _, mk := range marks {
+		if m.lists[mk.list].selectTask(mk.id) {
+			f(mk.list)
+		}
+	}
+
+	for i, index := range selected {
+		l := m.lists[i]
+		if index < 0 {
+			l.Deselect()
+		} else {
+			l.Select(min(index, max(len(l.Items())-1, 0)))
+		}
+		l.pruneMarks()
+	}
+}
+
+// pruneMarks removes the marks of tasks that are no longer in the list.
+func (lm *ListModel) pruneMarks() {
+	for id := range lm.marked {
+		if !slices.ContainsFunc(lm.allTasks(), func(item list.Item) bool {
+			return item.(scheduled.Task).ID == id
+		}) {
+			delete(lm.marked, id)
+		}
+	}
+}
+
+// MoveMarked moves each marked task by the given number of days, staying
+// within the Inbox and Sunday. Tasks shown on several days are not moved.
+func (m *Model) MoveMarked(days int) {
+	m.moveMarked(func(from int) int {
+		return min(max(from+days, Inbox), Sunday)
+	})
+}
+
+// MoveMarkedTo moves the marked tasks to the list at the given index.
+func (m *Model) MoveMarkedTo(to int) {
+	m.moveMarked(func(int) int {
+		return to
+	})
+}
+
+func (m *Model) moveMarked(target func(from int) int) {
+	m.eachMarked(func(from int) {
+		to := target(from)
+		id := m.lists[from].SelectedItem().(scheduled.Task).ID
+		_ = m.MoveTask(from, to)
+		if !m.lists[from].selectTask(id) && from != to {
+			delete(m.lists[from].marked, id)
+			m.lists[to].marked[id] = true
+		}
+	})
+}
+
+// ToggleMarkedDone checks the marked tasks, or unchecks them if all of them
+// are done.
+func (m *Model) ToggleMarkedDone() {
+	done := true
+	for i := Inbox; i <= Sunday; i++ {
+		for _, t := range m.lists[i].markedTasks() {
+			done = done && t.Done
+		}
+	}
+	m.eachMarked(func(listIndex int) {
+		if t, _ := m.GetSelectedTask(listIndex); t.Done == done {
+			m.ToggleDone(listIndex)
+		}
+	})
+}
+
+// DeleteMarked deletes the marked tasks that are done.
+func (m *Model) DeleteMarked() {
+	m.eachMarked(m.DeleteTask)
+}
+
+// SetMarkedContext assigns the marked tasks to the context with the given ID.
+func (m *Model) SetMarkedContext(id int) {
+	m.eachMarked(func(listIndex int) {
+		t, _ := m.GetSelectedTask(listIndex)
+		t.Context = id
+		m.replaceTask(t)
+	})
+	m.distribute(m.flattenTasks())
+}
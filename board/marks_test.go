@@ -0,0 +1,127 @@
+package board
+
+import (
+	"testing"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+func newMarkModel(t *testing.T) *Model {
+	t.Helper()
+	repo := &mockRepository{tasks: []scheduled.Task{
+		{ID: "a", Name: "A", Day: Inbox, Pos: 0},
+		{ID: "b", Name: "B", Day: Inbox, Pos: 1},
+		{ID: "c", Name: "C", Day: Monday},
+		{ID: "d", Name: "D", Day: Sunday},
+	}}
+	m := newTestModel(t, repo)
+	m.ToggleMark(Inbox)
+	m.lists[Sunday].Select(0)
+	m.ToggleMark(Sunday)
+	m.lists[Monday].Select(0)
+	return m
+}
+
+func names(tasks []scheduled.Task) []string {
+	var result []string
+	for _, t := range tasks {
+		result = append(result, t.Name)
+	}
+	return result
+}
+
+func TestModel_MarkedTasks(t *testing.T) {
+	m := newMarkModel(t)
+
+	if got := names(m.MarkedTasks()); len(got) != 2 || got[0] != "A" || got[1] != "D" {
+		t.Errorf("MarkedTasks() = %v, want [A D]", got)
+	}
+	if task, _ := m.GetSelectedTask(Inbox); task.ID != "b" {
+		t.Errorf("selected task = %s, want b after marking a", task.ID)
+	}
+
+	m.ClearMarks()
+	if m.HasMarks() {
+		t.Error("HasMarks() = true after ClearMarks()")
+	}
+}
+
+func TestModel_MoveMarked(t *testing.T) {
+	tests := []struct {
+		name string
+		move func(m *Model)
+		want map[int][]string
+	}{
+		{
+			name: "relative",
+			move: func(m *Model) { m.MoveMarked(2) },
+			want: map[int][]string{Inbox: {"B"}, Tuesday: {"A"}, Sunday: {"D"}},
+		},
+		{
+			name: "to a day",
+			move: func(m *Model) { m.MoveMarkedTo(Friday) },
+			want: map[int][]string{Inbox: {"B"}, Friday: {"A", "D"}, Sunday: nil},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newMarkModel(t)
+			tt.move(m)
+			for day, want := range tt.want {
+				got := names(m.GetTasksForPanel(day))
+				if len(got) != len(want) {
+					t.Fatalf("%s = %v, want %v", DayName(day), got, want)
+				}
+				for i := range got {
+					if got[i] != want[i] {
+						t.Errorf("%s = %v, want %v", DayName(day), got, want)
+					}
+				}
+			}
+			if got := len(m.MarkedTasks()); got != 2 {
+				t.Errorf("%d tasks marked, want the moved tasks to stay marked", got)
+			}
+			if task, _ := m.GetSelectedTask(Monday); task.ID != "c" {
+				t.Errorf("selected task = %s, want the selection to be kept", task.ID)
+			}
+		})
+	}
+}
+
+func TestModel_ToggleMarkedDone(t *testing.T) {
+	m := newMarkModel(t)
+	m.ToggleDone(Sunday)
+
+	m.ToggleMarkedDone()
+	for _, task := range m.MarkedTasks() {
+		if !task.Done {
+			t.Errorf("%s should be done", task.Name)
+		}
+	}
+
+	m.DeleteMarked()
+	if m.HasMarks() || len(m.GetTasksForPanel(Inbox)) != 1 || len(m.GetTasksForPanel(Sunday)) != 0 {
+		t.Errorf("DeleteMarked() should delete A and D, got %v", names(m.Tasks()))
+	}
+}
+
+func TestModel_SetMarkedContext(t *testing.T) {
+	m := newMarkModel(t)
+
+	m.SetMarkedContext(2)
+	for _, task := range m.Tasks() {
+		want := 0
+		if task.ID == "a" || task.ID == "d" {
+			want = 2
+		}
+		if task.Context != want {
+			t.Errorf("%s context = %d, want %d", task.Name, task.Context, want)
+		}
+	}
+
+	m.SetContext(scheduled.Context{ID: 2, Name: "work"})
+	if m.HasMarks() {
+		t.Error("changing the context should clear the marks")
+	}
+}
@@ -1,6 +1,7 @@
 package board
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 	"time"
@@ -10,6 +11,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/rwirdemann/scheduled"
 	"github.com/rwirdemann/scheduled/date"
+	"github.com/rwirdemann/scheduled/week"
 )
 
 const (
@@ -37,41 +39,76 @@ var days = map[int]string{
 // Model represents the main application model managing tasks and their context.
 type Model struct {
 	repository      repository
+	clock           date.Clock
 	LastFocus       int
 	lists           map[int]*ListModel
+	year            int
 	week            int
 	selectedContext scheduled.Context
+
+	// parked holds pinned tasks that are not scheduled for the current week.
+	parked []scheduled.Task
+
+	showDescription bool
+
+	// archive holds the deleted done tasks if the repository keeps them.
+	archive []scheduled.ArchivedTask
 }
 
 // NewModel creates a new instance of the application model with the provided
-// repository.
-func NewModel(repository repository) *Model {
+// repository, showing the current week of the clock. It fails if the tasks
+// can't be loaded.
+func NewModel(repository repository, clock date.Clock) (*Model, error) {
 	m := &Model{
 		repository:      repository,
+		clock:           clock,
 		LastFocus:       Inbox,
 		selectedContext: scheduled.ContextNone,
 		lists:           make(map[int]*ListModel),
 	}
-	defaultDelegate := list.NewDefaultDelegate()
-	defaultDelegate.ShowDescription = false
-	defaultDelegate.SetSpacing(0)
 	for i := Inbox; i <= Sunday; i++ {
-		l := list.New([]list.Item{}, defaultDelegate, 0, 0)
+		l := list.New([]list.Item{}, newDelegate(false), 0, 0)
 		l.SetShowStatusBar(false)
 		l.SetShowHelp(false)
 		m.lists[i] = NewListModel(l)
+		m.lists[i].setDelegate(false)
+	}
+
+	m.setWeek(clock.Now().ISOWeek())
+	if err := m.loadTasks(); err != nil {
+		return nil, err
+	}
+	if err := m.loadArchive(); err != nil {
+		return nil, err
 	}
-	m.loadTasks()
 
 	// Deselect all lists except the focused one (Inbox)
 	for i := Monday; i <= Sunday; i++ {
 		m.lists[i].Deselect()
 	}
 
-	_, w := time.Now().ISOWeek()
-	m.setWeek(w)
+	return m, nil
+}
+
+func newDelegate(showDescription bool) list.DefaultDelegate {
+	d := list.NewDefaultDelegate()
+	d.ShowDescription = showDescription
+	d.SetSpacing(0)
+	return d
+}
 
-	return m
+// SetShowDescription shows or hides the first line of the task descriptions
+// below the task titles.
+func (m *Model) SetShowDescription(show bool) {
+	m.showDescription = show
+	for _, l := range m.lists {
+		l.setDelegate(show)
+	}
+}
+
+// ShowDescription returns true if task descriptions are shown in the lists.
+func (m *Model) ShowDescription() bool {
+	return m.showDescription
 }
 
 // Week returns the current week number stored in the Model.
@@ -79,6 +116,26 @@ func (m *Model) Week() int {
 	return m.week
 }
 
+// Today returns the list of the current weekday of the clock.
+func (m *Model) Today() int {
+	return scheduled.Weekday(m.clock.Now())
+}
+
+// GoToWeek shows the given ISO week of the given year.
+func (m *Model) GoToWeek(year, week int) {
+	m.setWeek(year, week)
+}
+
+// DayName returns the name of the list with the given index, e.g. "Monday".
+func DayName(listIndex int) string {
+	return days[listIndex]
+}
+
+// Year returns the ISO year the current week belongs to.
+func (m *Model) Year() int {
+	return m.year
+}
+
 // GetSelectedContext returns the currently selected context in the Model.
 func (m *Model) GetSelectedContext() scheduled.Context {
 	return m.selectedContext
@@ -87,76 +144,119 @@ func (m *Model) GetSelectedContext() scheduled.Context {
 // SetContext sets the currently selected context in the Model.
 func (m *Model) SetContext(context scheduled.Context) {
 	m.selectedContext = context
+	m.ClearMarks()
 	for _, l := range m.lists {
 		l.SetContext(context)
 	}
 }
 
-// DecWeek decreases the current week, wrapping to 52 if below 1.
+// DecWeek decreases the current week, wrapping to the last week of the
+// previous year if below 1.
 func (m *Model) DecWeek() {
 	if m.week > 1 {
-		m.setWeek(m.week - 1)
+		m.setWeek(m.year, m.week-1)
 	} else {
-		m.setWeek(52)
+		m.setWeek(m.year-1, date.WeeksInYear(m.year-1))
 	}
 }
 
-// IncWeek increases the current week, wrapping to 1 if above 52.
+// IncWeek increases the current week, wrapping to week 1 of the next year
+// after the last week of the year.
 func (m *Model) IncWeek() {
-	if m.week < 52 {
-		m.setWeek(m.week + 1)
+	if m.week < date.WeeksInYear(m.year) {
+		m.setWeek(m.year, m.week+1)
 	} else {
-		m.setWeek(1)
+		m.setWeek(m.year+1, 1)
 	}
 }
 
-func (m *Model) loadTasks() {
-	var tasksByDay = make(map[int][]list.Item)
-	tasks := m.repository.LoadTasks()
+func (m *Model) loadTasks() error {
+	tasks, err := m.repository.LoadTasks()
+	if err != nil {
+		return err
+	}
+
+	m.distribute(tasks)
+	return nil
+}
+
+// distribute assigns the given tasks to the lists of the current week. Tasks
+// that are not scheduled for the current week are parked.
+func (m *Model) distribute(tasks []scheduled.Task) {
+	var tasksByDay = make(map[int][]scheduled.Task)
+	m.parked = nil
 	for _, task := range tasks {
-		tasksByDay[task.Day] = append(tasksByDay[task.Day], task)
+		days := m.calendar().Days(task)
+		if len(days) == 0 {
+			m.parked = append(m.parked, task)
+			continue
+		}
+		for _, day := range days {
+			t := task
+			if t.Pinned() {
+				t.Day = day
+			}
+			if day != Inbox {
+				t.Done = t.DoneOn(m.dateOf(day))
+			}
+			tasksByDay[day] = append(tasksByDay[day], t)
+		}
 	}
 
 	// Sort tasks by their Pos field
 	for _, items := range tasksByDay {
-		sort.Slice(items, func(i, j int) bool {
-			return items[i].(scheduled.Task).Pos < items[j].(scheduled.Task).Pos
+		sort.SliceStable(items, func(i, j int) bool {
+			return items[i].Pos < items[j].Pos
 		})
 	}
 
-	for day := range m.lists {
-		for i, item := range tasksByDay[day] {
-			m.lists[day].InsertItem(i, item)
-		}
+	for day, l := range m.lists {
+		l.SetTasks(tasksByDay[day])
 	}
 }
 
-// UpdateTask updates the name and context of the task with the given ID.
-func (m *Model) UpdateTask(name string, context int) {
-	l := m.lists[m.LastFocus]
-	oldTask := l.SelectedItem().(scheduled.Task)
-	task := oldTask
-	task.Name = name
-	task.Context = context
-	index := l.Index()
-	l.RemoveItem(index)
-	l.InsertItem(index, task)
+// calendar returns the current week, which tells the lists a task belongs to
+// like everywhere else tasks are shown.
+func (m *Model) calendar() *week.Week {
+	return week.New(nil, m.dateOf(Monday))
+}
 
-	// Synchronize allItems when a context filter is active
-	if l.allItems != nil {
-		for i, item := range l.allItems {
-			t := item.(scheduled.Task)
-			if t.ID == oldTask.ID {
-				l.allItems[i] = task
-				break
-			}
-		}
+// dateOf returns the date of the given day list in the current week.
+func (m *Model) dateOf(listIndex int) time.Time {
+	return date.GetMondayOfWeek(m.year, m.week).AddDate(0, 0, listIndex-1)
+}
+
+// UpdateTask updates the name, description, context and recurrence of the
+// selected task with the values of the given task.
+func (m *Model) UpdateTask(edited scheduled.Task) {
+	item := m.lists[m.LastFocus].SelectedItem()
+	if item == nil {
+		return
+	}
+	task := item.(scheduled.Task)
+	task.Name = edited.Name
+	task.Desc = edited.Desc
+	task.Context = edited.Context
+	if task.Recurrence.String() == edited.Recurrence.String() {
+		m.replaceTask(task)
+		return
+	}
+
+	// A new recurrence may change the days the task is shown on
+	task.Recurrence = m.calendar().Anchor(edited.Recurrence)
+	if task.Recurrence != nil {
+		task.Unpin()
 	}
+	m.replaceTask(task)
+	m.distribute(m.flattenTasks())
 }
 
-// CreateTask creates a new task with the given name and context.
-func (m *Model) CreateTask(name string, context int) {
-	t := scheduled.Task{Name: name, Context: context, Day: m.LastFocus, ID: uuid.NewString()}
+// CreateTask adds the given task to the focused list.
+func (m *Model) CreateTask(task scheduled.Task) {
+	t := task
+	t.ID = uuid.NewString()
+	t.Day = m.LastFocus
+	t.Recurrence = m.calendar().Anchor(t.Recurrence)
 	l := m.lists[m.LastFocus]
 	l.InsertItem(len(l.Items()), t)
 
@@ -164,6 +264,21 @@ func (m *Model) CreateTask(name string, context int) {
 	if l.allItems != nil {
 		l.allItems = append(l.allItems, t)
 	}
+
+	if t.Recurrence != nil {
+		m.distribute(m.flattenTasks())
+	}
+}
+
+// replaceTask replaces all copies of the given task in all lists.
+func (m *Model) replaceTask(task scheduled.Task) {
+	for day, l := range m.lists {
+		t := task
+		if day != Inbox {
+			t.Done = t.DoneOn(m.dateOf(day))
+		}
+		l.replaceTask(t)
+	}
 }
 
 // SetListTitle sets the title of the list at the given index.
@@ -185,15 +300,30 @@ func (m *Model) MoveDown(listIndex int) {
 	}
 }
 
+// SelectFirst selects the first task in the list at the given index.
+func (m *Model) SelectFirst(listIndex int) {
+	if l, exists := m.lists[listIndex]; exists {
+		l.Select(0)
+	}
+}
+
+// SelectLast selects the last task in the list at the given index.
+func (m *Model) SelectLast(listIndex int) {
+	if l, exists := m.lists[listIndex]; exists && len(l.Items()) > 0 {
+		l.Select(len(l.Items()) - 1)
+	}
+}
+
 // ToggleDone toggles the done state of the selected task in the list at the
 // given index.
 func (m *Model) ToggleDone(listIndex int) {
-	if l, exists := m.lists[listIndex]; exists {
-		l.ToggleDone()
+	if l, exists := m.lists[listIndex]; exists && l.ToggleDone(m.clock.Now()) {
+		m.replaceTask(l.SelectedItem().(scheduled.Task))
 	}
 }
 
-// DeleteTask deletes the selected task in the list at the given index.
+// DeleteTask deletes the selected task in the list at the given index, if it
+// is done. Deleted tasks are archived.
 func (m *Model) DeleteTask(listIndex int) {
 	if l, exists := m.lists[listIndex]; exists {
 		i := l.SelectedItem()
@@ -202,40 +332,36 @@ func (m *Model) DeleteTask(listIndex int) {
 		}
 		task := i.(scheduled.Task)
 		if task.Done {
-			l.RemoveItem(l.Index())
-
-			// Synchronize allItems when a context filter is active
-			if l.allItems != nil {
-				for idx, item := range l.allItems {
-					t := item.(scheduled.Task)
-					if t.ID == task.ID {
-						l.allItems = append(l.allItems[:idx], l.allItems[idx+1:]...)
-						break
-					}
-				}
+			for _, l := range m.lists {
+				l.removeTask(task.ID)
 			}
+			m.archiveTask(task)
 		}
 	}
 }
 
-// MoveTask moves the selected task from one list to another.
-func (m *Model) MoveTask(from, to int) {
+// MoveTask moves the selected task from one list to another. Tasks recurring
+// on several days can't be moved.
+func (m *Model) MoveTask(from, to int) error {
 	if from < Inbox || from > Sunday {
-		return
+		return nil
 	}
 
 	if to < Inbox || to > Sunday {
-		return
+		return nil
 	}
 
 	if from == to {
-		return
+		return nil
 	}
 
 	if item := m.lists[from].SelectedItem(); item != nil {
 		oldTask := item.(scheduled.Task)
+		if oldTask.MultiDay() {
+			return fmt.Errorf("'%s' recurs on several days and can't be moved", oldTask.Name)
+		}
 		t := oldTask
-		t.Day = to
+		t.MoveTo(to, m.dateOf(to))
 		m.lists[from].RemoveItem(m.lists[from].Index())
 		m.lists[to].InsertItem(len(m.lists[to].Items()), t)
 
@@ -254,6 +380,39 @@ func (m *Model) MoveTask(from, to int) {
 			m.lists[to].allItems = append(m.lists[to].allItems, t)
 		}
 	}
+	return nil
+}
+
+// TogglePin pins the selected task in the list at the given index to the date
+// of that list in the current week, or unpins it if it is already pinned.
+func (m *Model) TogglePin(listIndex int) error {
+	l, exists := m.lists[listIndex]
+	if !exists {
+		return nil
+	}
+	item := l.SelectedItem()
+	if item == nil {
+		return nil
+	}
+	if listIndex == Inbox {
+		return errors.New("Inbox tasks can not be pinned")
+	}
+
+	oldTask := item.(scheduled.Task)
+	t := oldTask
+	if t.Pinned() {
+		t.Unpin()
+	} else {
+		t.Pin(m.dateOf(listIndex))
+		t.Day = listIndex
+		t.Recurrence = nil
+	}
+	t.SetDoneOn(m.dateOf(listIndex), oldTask.Done)
+	m.replaceTask(t)
+	if oldTask.MultiDay() {
+		m.distribute(m.flattenTasks())
+	}
+	return nil
 }
 
 // GetSelectedTask returns the selected task in the list at the given index,
@@ -304,14 +463,27 @@ func (m *Model) DeselectAndRestoreIndex(focusedPanelID int) {
 	}
 }
 
-// SaveTasks saves the tasks in the model to the repository.
-func (m *Model) SaveTasks() {
-	m.repository.SaveTasks(m.flattenTasks())
+// Tasks returns all tasks of the board, including tasks that are not
+// scheduled for the current week.
+func (m *Model) Tasks() []scheduled.Task {
+	return m.flattenTasks()
+}
+
+// Restore replaces all tasks of the board with the given tasks.
+func (m *Model) Restore(tasks []scheduled.Task) {
+	m.distribute(tasks)
+}
+
+// SaveTasks saves the tasks and the archive in the model to the repository.
+func (m *Model) SaveTasks() error {
+	return errors.Join(m.repository.SaveTasks(m.flattenTasks()), m.saveArchive())
 }
 
 func (m *Model) flattenTasks() []scheduled.Task {
 	var tasks []scheduled.Task
-	for _, ll := range m.lists {
+	seen := make(map[string]int)
+	for day := Inbox; day <= Sunday; day++ {
+		ll := m.lists[day]
 		var itemsToSave []list.Item
 		if ll.allItems != nil {
 			itemsToSave = ll.allItems
@@ -322,10 +494,25 @@ func (m *Model) flattenTasks() []scheduled.Task {
 		for i, item := range itemsToSave {
 			t := item.(scheduled.Task)
 			t.Pos = i
+
+			// Done of rolling tasks only reflects the current week
+			if t.Rolling() {
+				t.Done = false
+			}
+
+			// Tasks shown on several days are saved once, preferably with the
+			// position in the list of their own day
+			if j, ok := seen[t.ID]; ok {
+				if day == t.Day {
+					tasks[j] = t
+				}
+				continue
+			}
+			seen[t.ID] = len(tasks)
 			tasks = append(tasks, t)
 		}
 	}
-	return tasks
+	return append(tasks, m.parked...)
 }
 
 // Render returns the rendered view of the list at the given index.
@@ -348,20 +535,25 @@ func (m *Model) IsContextUsed(c scheduled.Context) bool {
 	return false
 }
 
-func (m *Model) setWeek(week int) {
+func (m *Model) setWeek(year, week int) {
+	tasks := m.flattenTasks()
+	m.ClearMarks()
+	m.year = year
 	m.week = week
 	for i := Inbox; i <= Sunday; i++ {
-		monday := date.GetMondayOfWeek(m.week)
 		if i == Inbox {
-			m.lists[i].Title = fmt.Sprintf("[ESC] Inbox (Week %d) - %s", m.week, m.selectedContext.Name)
+			m.lists[i].Title = fmt.Sprintf("[ESC] Inbox (Week %d, %d) - %s", m.week, m.year, m.selectedContext.Name)
 		} else {
-			day := monday.AddDate(0, 0, i-1)
-			m.lists[i].Title = fmt.Sprintf("[%d] %s (%s)", i, days[i], day.Format("02.01.2006"))
+			m.lists[i].SetDate(m.dateOf(i))
+			m.lists[i].Title = fmt.Sprintf("[%d] %s (%s)", i, days[i], m.dateOf(i).Format("02.01.2006"))
 		}
 	}
+	m.distribute(tasks)
 }
 
 type repository interface {
-	LoadTasks() []scheduled.Task
-	SaveTasks(tasks []scheduled.Task)
+	LoadTasks() ([]scheduled.Task, error)
+	SaveTasks(tasks []scheduled.Task) error
+	LoadArchive() ([]scheduled.ArchivedTask, error)
+	SaveArchive(archive []scheduled.ArchivedTask) error
 }
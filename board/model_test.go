@@ -1,39 +1,112 @@
 package board
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
 )
 
 // Mock repository for testing
 type mockRepository struct {
-	tasks []scheduled.Task
+	tasks   []scheduled.Task
+	archive []scheduled.ArchivedTask
+	loadErr error
 }
 
-func (m *mockRepository) LoadTasks() []scheduled.Task {
-	return m.tasks
+func (m *mockRepository) LoadTasks() ([]scheduled.Task, error) {
+	return m.tasks, m.loadErr
 }
 
-func (m *mockRepository) SaveTasks(tasks []scheduled.Task) {
+func (m *mockRepository) SaveTasks(tasks []scheduled.Task) error {
 	m.tasks = tasks
+	return nil
+}
+
+func (m *mockRepository) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	return m.archive, nil
+}
+
+func (m *mockRepository) SaveArchive(archive []scheduled.ArchivedTask) error {
+	m.archive = archive
+	return nil
+}
+
+// newTestModel creates a model for the given repository and fails the test if
+// the tasks can't be loaded.
+func newTestModel(t *testing.T, repo *mockRepository) *Model {
+	t.Helper()
+	m, err := NewModel(repo, testClock)
+	if err != nil {
+		t.Fatalf("NewModel() error = %v", err)
+	}
+	return m
+}
+
+// testClock is Wednesday of week 10 in 2026.
+var testClock = date.Fixed(time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local))
+
+func TestModel_NewModelShowsWeekOfClock(t *testing.T) {
+	tests := []struct {
+		name  string
+		now   time.Time
+		year  int
+		week  int
+		today int
+	}{
+		{"wednesday", time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local), 2026, 10, Wednesday},
+		{"new year's day in week 53", time.Date(2021, time.January, 1, 9, 0, 0, 0, time.Local), 2020, 53, Friday},
+		{"sunday", time.Date(2026, time.October, 18, 9, 0, 0, 0, time.Local), 2026, 42, Sunday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewModel(&mockRepository{}, date.Fixed(tt.now))
+			if err != nil {
+				t.Fatalf("NewModel() error = %v", err)
+			}
+			if m.Year() != tt.year || m.Week() != tt.week {
+				t.Errorf("Year(), Week() = %d, %d, want %d, %d", m.Year(), m.Week(), tt.year, tt.week)
+			}
+			if m.Today() != tt.today {
+				t.Errorf("Today() = %d, want %d", m.Today(), tt.today)
+			}
+		})
+	}
 }
 
 func TestModel_DecWeek(t *testing.T) {
 	tests := []struct {
 		name         string
+		initialYear  int
 		initialWeek  int
+		expectedYear int
 		expectedWeek int
 	}{
 		{
 			name:         "decrement normal week",
+			initialYear:  2026,
 			initialWeek:  10,
+			expectedYear: 2026,
 			expectedWeek: 9,
 		},
 		{
-			name:         "wrap from week 1 to week 52",
+			name:         "wrap from week 1 to week 53 of the previous year",
+			initialYear:  2021,
 			initialWeek:  1,
+			expectedYear: 2020,
+			expectedWeek: 53,
+		},
+		{
+			name:         "wrap from week 1 to week 52 of the previous year",
+			initialYear:  2026,
+			initialWeek:  1,
+			expectedYear: 2025,
 			expectedWeek: 52,
 		},
 	}
@@ -41,13 +114,13 @@ func TestModel_DecWeek(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &mockRepository{tasks: []scheduled.Task{}}
-			m := NewModel(repo)
-			m.setWeek(tt.initialWeek)
+			m := newTestModel(t, repo)
+			m.setWeek(tt.initialYear, tt.initialWeek)
 
 			m.DecWeek()
 
-			if m.Week() != tt.expectedWeek {
-				t.Errorf("Week() = %d, want %d", m.Week(), tt.expectedWeek)
+			if m.Year() != tt.expectedYear || m.Week() != tt.expectedWeek {
+				t.Errorf("Year(), Week() = %d, %d, want %d, %d", m.Year(), m.Week(), tt.expectedYear, tt.expectedWeek)
 			}
 		})
 	}
@@ -56,17 +129,37 @@ func TestModel_DecWeek(t *testing.T) {
 func TestModel_IncWeek(t *testing.T) {
 	tests := []struct {
 		name         string
+		initialYear  int
 		initialWeek  int
+		expectedYear int
 		expectedWeek int
 	}{
 		{
 			name:         "increment normal week",
+			initialYear:  2026,
 			initialWeek:  10,
+			expectedYear: 2026,
 			expectedWeek: 11,
 		},
 		{
-			name:         "wrap from week 52 to week 1",
+			name:         "increment to week 53",
+			initialYear:  2026,
+			initialWeek:  52,
+			expectedYear: 2026,
+			expectedWeek: 53,
+		},
+		{
+			name:         "wrap from week 53 to week 1 of the next year",
+			initialYear:  2020,
+			initialWeek:  53,
+			expectedYear: 2021,
+			expectedWeek: 1,
+		},
+		{
+			name:         "wrap from week 52 to week 1 of the next year",
+			initialYear:  2025,
 			initialWeek:  52,
+			expectedYear: 2026,
 			expectedWeek: 1,
 		},
 	}
@@ -74,13 +167,46 @@ func TestModel_IncWeek(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			repo := &mockRepository{tasks: []scheduled.Task{}}
-			m := NewModel(repo)
-			m.setWeek(tt.initialWeek)
+			m := newTestModel(t, repo)
+			m.setWeek(tt.initialYear, tt.initialWeek)
 
 			m.IncWeek()
 
-			if m.Week() != tt.expectedWeek {
-				t.Errorf("Week() = %d, want %d", m.Week(), tt.expectedWeek)
+			if m.Year() != tt.expectedYear || m.Week() != tt.expectedWeek {
+				t.Errorf("Year(), Week() = %d, %d, want %d, %d", m.Year(), m.Week(), tt.expectedYear, tt.expectedWeek)
+			}
+		})
+	}
+}
+
+func TestModel_WeekDates(t *testing.T) {
+	tests := []struct {
+		name   string
+		year   int
+		week   int
+		inbox  string
+		monday string
+		sunday string
+	}{
+		{"week 53 of 2020", 2020, 53, "Week 53, 2020", "28.12.2020", "03.01.2021"},
+		{"week 1 of 2026", 2026, 1, "Week 1, 2026", "29.12.2025", "04.01.2026"},
+		{"week 53 of 2026", 2026, 53, "Week 53, 2026", "28.12.2026", "03.01.2027"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{tasks: []scheduled.Task{}}
+			m := newTestModel(t, repo)
+			m.setWeek(tt.year, tt.week)
+
+			if !strings.Contains(m.lists[Inbox].Title, tt.inbox) {
+				t.Errorf("Inbox title = %q, want it to contain %q", m.lists[Inbox].Title, tt.inbox)
+			}
+			if got := m.dateOf(Monday).Format("02.01.2006"); got != tt.monday {
+				t.Errorf("Monday = %s, want %s", got, tt.monday)
+			}
+			if got := m.dateOf(Sunday).Format("02.01.2006"); got != tt.sunday {
+				t.Errorf("Sunday = %s, want %s", got, tt.sunday)
 			}
 		})
 	}
@@ -88,13 +214,13 @@ func TestModel_IncWeek(t *testing.T) {
 
 func TestModel_CreateTask(t *testing.T) {
 	repo := &mockRepository{tasks: []scheduled.Task{}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 	m.LastFocus = Monday
 
 	initialCount := len(m.GetTasksForPanel(Monday))
 
 	// Create a new task
-	m.CreateTask("New Task", 1)
+	m.CreateTask(scheduled.Task{Name: "New Task", Context: 1})
 
 	tasks := m.GetTasksForPanel(Monday)
 	if len(tasks) != initialCount+1 {
@@ -123,14 +249,14 @@ func TestModel_UpdateTask(t *testing.T) {
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 	m.LastFocus = Monday
 
 	// Select the task first
 	m.lists[Monday].Select(0)
 
 	// Update the task
-	m.UpdateTask("Updated Name", 2)
+	m.UpdateTask(scheduled.Task{Name: "Updated Name", Desc: "First line\nSecond line", Context: 2})
 
 	tasks := m.GetTasksForPanel(Monday)
 	if len(tasks) != 1 {
@@ -144,6 +270,9 @@ func TestModel_UpdateTask(t *testing.T) {
 	if updatedTask.Context != 2 {
 		t.Errorf("Task context = %d, want 2", updatedTask.Context)
 	}
+	if updatedTask.Description() != "First line" {
+		t.Errorf("Task description = %s, want 'First line'", updatedTask.Description())
+	}
 }
 
 func TestModel_MoveTask(t *testing.T) {
@@ -154,7 +283,7 @@ func TestModel_MoveTask(t *testing.T) {
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	// Select the task first
 	m.lists[Monday].Select(0)
@@ -190,7 +319,7 @@ func TestModel_MoveTask_InvalidRange(t *testing.T) {
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	// Select the task first
 	m.lists[Monday].Select(0)
@@ -205,16 +334,38 @@ func TestModel_MoveTask_InvalidRange(t *testing.T) {
 	}
 }
 
+func TestModel_MoveTask_MultiDay(t *testing.T) {
+	task := scheduled.Task{
+		ID:         uuid.NewString(),
+		Name:       "Standup",
+		Day:        Monday,
+		Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays},
+	}
+
+	repo := &mockRepository{tasks: []scheduled.Task{task}}
+	m := newTestModel(t, repo)
+	m.lists[Monday].Select(0)
+
+	if err := m.MoveTask(Monday, Saturday); err == nil {
+		t.Error("MoveTask() should fail for tasks recurring on several days")
+	}
+	if len(m.GetTasksForPanel(Saturday)) != 0 {
+		t.Error("Task should not be moved to Saturday")
+	}
+}
+
 func TestModel_DeleteTask(t *testing.T) {
 	task := scheduled.Task{
 		ID:   uuid.NewString(),
 		Name: "Task to Delete",
 		Day:  Monday,
-		Done: true, // Must be done to be deletable
+
+		// Must be done to be deletable
+		DoneWeeks: []string{"2026-W10"},
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	initialCount := len(m.GetTasksForPanel(Monday))
 
@@ -239,7 +390,7 @@ func TestModel_DeleteTask_NotDone(t *testing.T) {
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	// Select the task first
 	m.lists[Monday].Select(0)
@@ -263,7 +414,7 @@ func TestModel_ToggleDone(t *testing.T) {
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	// Select the task first
 	m.lists[Monday].Select(0)
@@ -283,7 +434,7 @@ func TestModel_ToggleDone(t *testing.T) {
 
 func TestModel_SetContext(t *testing.T) {
 	repo := &mockRepository{tasks: []scheduled.Task{}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	context := scheduled.Context{ID: 1, Name: "Work"}
 	m.SetContext(context)
@@ -298,7 +449,7 @@ func TestModel_IsContextUsed(t *testing.T) {
 	task2 := scheduled.Task{ID: uuid.NewString(), Name: "Task 2", Context: 2, Day: Tuesday}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task1, task2}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	workContext := scheduled.Context{ID: 1, Name: "Work"}
 	homeContext := scheduled.Context{ID: 2, Name: "Home"}
@@ -322,10 +473,12 @@ func TestModel_SaveTasks(t *testing.T) {
 	task2 := scheduled.Task{ID: uuid.NewString(), Name: "Task 2", Day: Tuesday}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task1, task2}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	// Modify and save
-	m.SaveTasks()
+	if err := m.SaveTasks(); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
 
 	// Verify positions are set
 	savedTasks := repo.tasks
@@ -354,7 +507,7 @@ func TestModel_GetSelectedTask(t *testing.T) {
 	}
 
 	repo := &mockRepository{tasks: []scheduled.Task{task}}
-	m := NewModel(repo)
+	m := newTestModel(t, repo)
 
 	selectedTask, ok := m.GetSelectedTask(Inbox)
 	if !ok {
@@ -365,3 +518,178 @@ func TestModel_GetSelectedTask(t *testing.T) {
 		t.Errorf("Selected task name = %s, want 'Selected Task'", selectedTask.Name)
 	}
 }
+
+func TestModel_PinnedTaskOnlyInItsWeek(t *testing.T) {
+	repo := &mockRepository{tasks: []scheduled.Task{}}
+	m := newTestModel(t, repo)
+	m.setWeek(2026, 10)
+
+	pinned := scheduled.Task{ID: uuid.NewString(), Name: "Pinned", Day: Wednesday}
+	pinned.Pin(m.dateOf(Wednesday))
+	rolling := scheduled.Task{ID: uuid.NewString(), Name: "Rolling", Day: Wednesday}
+	m.distribute([]scheduled.Task{pinned, rolling})
+
+	if tasks := m.GetTasksForPanel(Wednesday); len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks in week 10, got %d", len(tasks))
+	}
+
+	m.IncWeek()
+	tasks := m.GetTasksForPanel(Wednesday)
+	if len(tasks) != 1 || tasks[0].ID != rolling.ID {
+		t.Fatalf("Expected only the rolling task in week 11, got %v", tasks)
+	}
+	if len(m.flattenTasks()) != 2 {
+		t.Error("Pinned task should still be saved when it is not visible")
+	}
+
+	m.DecWeek()
+	if tasks := m.GetTasksForPanel(Wednesday); len(tasks) != 2 {
+		t.Errorf("Expected 2 tasks after returning to week 10, got %d", len(tasks))
+	}
+}
+
+func TestModel_TogglePin(t *testing.T) {
+	task := scheduled.Task{ID: uuid.NewString(), Name: "Task", Day: Friday}
+	repo := &mockRepository{tasks: []scheduled.Task{task}}
+	m := newTestModel(t, repo)
+	m.lists[Friday].Select(0)
+
+	if err := m.TogglePin(Friday); err != nil {
+		t.Fatalf("TogglePin() error = %v", err)
+	}
+	pinned := m.GetTasksForPanel(Friday)[0]
+	if d, ok := pinned.PinnedDate(); !ok || !d.Equal(m.dateOf(Friday)) {
+		t.Errorf("Task should be pinned to %s, got %q", m.dateOf(Friday).Format(scheduled.DateFormat), pinned.Date)
+	}
+
+	if err := m.TogglePin(Friday); err != nil {
+		t.Fatalf("TogglePin() error = %v", err)
+	}
+	if m.GetTasksForPanel(Friday)[0].Pinned() {
+		t.Error("Task should be unpinned after second toggle")
+	}
+}
+
+func TestModel_TogglePin_Inbox(t *testing.T) {
+	task := scheduled.Task{ID: uuid.NewString(), Name: "Task", Day: Inbox}
+	repo := &mockRepository{tasks: []scheduled.Task{task}}
+	m := newTestModel(t, repo)
+
+	if err := m.TogglePin(Inbox); err == nil {
+		t.Error("Inbox tasks should not be pinnable")
+	}
+}
+
+func TestModel_ToggleDone_PerWeek(t *testing.T) {
+	task := scheduled.Task{ID: uuid.NewString(), Name: "Recurring", Day: Monday}
+	repo := &mockRepository{tasks: []scheduled.Task{task}}
+	m := newTestModel(t, repo)
+	m.setWeek(2026, 10)
+	m.lists[Monday].Select(0)
+
+	m.ToggleDone(Monday)
+	if !m.GetTasksForPanel(Monday)[0].Done {
+		t.Fatal("Task should be done in week 10")
+	}
+
+	m.IncWeek()
+	if m.GetTasksForPanel(Monday)[0].Done {
+		t.Error("Task should not be done in week 11")
+	}
+
+	m.DecWeek()
+	if !m.GetTasksForPanel(Monday)[0].Done {
+		t.Error("Task should still be done in week 10")
+	}
+}
+
+func TestModel_Recurrence(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  string
+		day   int
+		weeks map[int][]int // week -> days the task is shown on
+	}{
+		{
+			name:  "without rule every week",
+			rule:  "",
+			day:   Tuesday,
+			weeks: map[int][]int{10: {Tuesday}, 11: {Tuesday}, 12: {Tuesday}},
+		},
+		{
+			name:  "every 2 weeks",
+			rule:  "weekly:2",
+			day:   Tuesday,
+			weeks: map[int][]int{10: {Tuesday}, 11: nil, 12: {Tuesday}},
+		},
+		{
+			name:  "every weekday",
+			rule:  "weekdays",
+			day:   Monday,
+			weeks: map[int][]int{10: {Monday, Tuesday, Wednesday, Thursday, Friday}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{tasks: []scheduled.Task{}}
+			m := newTestModel(t, repo)
+			m.setWeek(2026, 10)
+			m.LastFocus = tt.day
+
+			r, err := scheduled.ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence() error = %v", err)
+			}
+			m.CreateTask(scheduled.Task{Name: "Chore", Recurrence: r})
+
+			for week, want := range tt.weeks {
+				m.setWeek(2026, week)
+				var got []int
+				for day := Monday; day <= Sunday; day++ {
+					if len(m.GetTasksForPanel(day)) > 0 {
+						got = append(got, day)
+					}
+				}
+				if fmt.Sprint(got) != fmt.Sprint(want) {
+					t.Errorf("week %d: task shown on %v, want %v", week, got, want)
+				}
+			}
+
+			if n := len(m.flattenTasks()); n != 1 {
+				t.Errorf("Expected 1 task to be saved, got %d", n)
+			}
+		})
+	}
+}
+
+func TestModel_ToggleDone_MultiDay(t *testing.T) {
+	repo := &mockRepository{tasks: []scheduled.Task{}}
+	m := newTestModel(t, repo)
+	m.LastFocus = Monday
+	m.CreateTask(scheduled.Task{Name: "Standup", Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}})
+
+	m.lists[Wednesday].Select(0)
+	m.ToggleDone(Wednesday)
+
+	if !m.GetTasksForPanel(Wednesday)[0].Done {
+		t.Error("Task should be done on Wednesday")
+	}
+	if m.GetTasksForPanel(Monday)[0].Done {
+		t.Error("Task should not be done on Monday")
+	}
+
+	if err := m.SaveTasks(); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+	if len(repo.tasks) != 1 || len(repo.tasks[0].DoneWeeks) != 1 {
+		t.Errorf("Expected one saved task with one done date, got %v", repo.tasks)
+	}
+}
+
+func TestNewModel_LoadError(t *testing.T) {
+	repo := &mockRepository{loadErr: errors.New("corrupted")}
+	if _, err := NewModel(repo, testClock); err == nil {
+		t.Error("NewModel() should fail if tasks can't be loaded")
+	}
+}
@@ -0,0 +1,126 @@
+package board
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/list"
+	"github.com/rwirdemann/scheduled"
+)
+
+// searchDays limits how far ahead Search looks for the next occurrence of
+// tasks that are not scheduled for the current week.
+const searchDays = 366
+
+// Match is a task found by Search.
+type Match struct {
+	Task scheduled.Task
+
+	// List is the list the task is shown in.
+	List int
+
+	// Date is the day the task is shown on. It is zero for Inbox tasks.
+	Date time.Time
+}
+
+// Day describes where the task is shown, e.g. "Wednesday 04.03.2026".
+func (mt Match) Day() string {
+	if mt.List == Inbox {
+		return days[Inbox]
+	}
+	return fmt.Sprintf("%s %s", days[mt.List], mt.Date.Format("02.01.2006"))
+}
+
+// Search returns the tasks whose name or description contains the query,
+// ignoring case. It searches all lists including tasks hidden by the context
+// filter, and tasks of other weeks, which are found on their next date.
+// Tasks shown on several days are only returned once.
+func (m *Model) Search(query string) []Match {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return nil
+	}
+	matches := func(t scheduled.Task) bool {
+		return strings.Contains(strings.ToLower(t.Name), query) || strings.Contains(strings.ToLower(t.Desc), query)
+	}
+
+	var result []Match
+	found := make(map[string]bool)
+	for i := Inbox; i <= Sunday; i++ {
+		for _, item := range m.lists[i].allTasks() {
+			t := item.(scheduled.Task)
+			if found[t.ID] || !matches(t) {
+				continue
+			}
+			found[t.ID] = true
+			match := Match{Task: t, List: i}
+			if i != Inbox {
+				match.Date = m.dateOf(i)
+			}
+			result = append(result, match)
+		}
+	}
+	for _, t := range m.parked {
+		if !matches(t) {
+			continue
+		}
+		if d, ok := m.nextDate(t); ok {
+			result = append(result, Match{Task: t, List: scheduled.Weekday(d), Date: d})
+		}
+	}
+	return result
+}
+
+// nextDate returns the date a task that is not scheduled for the current
+// week is shown on next. Pinned tasks are shown on their date, even if it has
+// passed.
+func (m *Model) nextDate(t scheduled.Task) (time.Time, bool) {
+	if d, ok := t.PinnedDate(); ok {
+		return d, true
+	}
+	d := m.dateOf(Monday)
+	for range searchDays {
+		if t.OccursOn(d) {
+			return d, true
+		}
+		d = d.AddDate(0, 0, 1)
+	}
+	return time.Time{}, false
+}
+
+// Reveal shows the week of the match and selects its task. The context filter
+// is cleared if it hides the task.
+func (m *Model) Reveal(match Match) {
+	if !match.Date.IsZero() {
+		if year, week := match.Date.ISOWeek(); year != m.year || week != m.week {
+			m.setWeek(year, week)
+		}
+	}
+	l := m.lists[match.List]
+	if !l.selectTask(match.Task.ID) {
+		m.SetContext(scheduled.ContextNone)
+		l.selectTask(match.Task.ID)
+	}
+}
+
+// allTasks returns the items of the list including the ones hidden by the
+// context filter.
+func (lm *ListModel) allTasks() []list.Item {
+	if lm.allItems != nil {
+		return lm.allItems
+	}
+	return lm.Items()
+}
+
+// selectTask selects the task with the given ID and returns false if it is
+// not shown.
+func (lm *ListModel) selectTask(id string) bool {
+	for i, item := range lm.Items() {
+		if item.(scheduled.Task).ID == id {
+			lm.Select(i)
+			return true
+		}
+	}
+	return false
+}
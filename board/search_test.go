@@ -0,0 +1,77 @@
+package board
+
+import (
+	"testing"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+func newSearchModel(t *testing.T) *Model {
+	t.Helper()
+	repo := &mockRepository{tasks: []scheduled.Task{
+		{ID: "inbox", Name: "Buy milk", Day: Inbox},
+		{ID: "work", Name: "Write report", Day: Monday, Context: 2},
+		{ID: "notes", Name: "Call Anna", Desc: "About the REPORT", Day: Friday},
+		{ID: "pinned", Name: "Report to tax office", Day: Tuesday, Date: "2026-04-14"},
+		{ID: "standup", Name: "Standup report", Day: Monday, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}},
+	}}
+	return newTestModel(t, repo)
+}
+
+func TestModel_Search(t *testing.T) {
+	m := newSearchModel(t)
+	m.SetContext(scheduled.Context{ID: 3, Name: "home"})
+
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"", nil},
+		{"milk", []string{"Inbox"}},
+		{"report", []string{"Monday 02.03.2026", "Monday 02.03.2026", "Friday 06.03.2026", "Tuesday 14.04.2026"}},
+		{"anna", []string{"Friday 06.03.2026"}},
+		{"nothing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			for _, match := range m.Search(tt.query) {
+				got = append(got, match.Day())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Search(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Search(%q)[%d] = %s, want %s", tt.query, i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestModel_Reveal(t *testing.T) {
+	m := newSearchModel(t)
+	m.SetContext(scheduled.Context{ID: 3, Name: "home"})
+
+	// Hidden by the context filter
+	matches := m.Search("write")
+	m.Reveal(matches[0])
+	if m.GetSelectedContext() != scheduled.ContextNone {
+		t.Errorf("context = %v, want the filter to be cleared", m.GetSelectedContext())
+	}
+	if task, _ := m.GetSelectedTask(Monday); task.ID != "work" {
+		t.Errorf("selected task = %s, want work", task.ID)
+	}
+
+	// In another week
+	matches = m.Search("tax")
+	m.Reveal(matches[0])
+	if m.Year() != 2026 || m.Week() != 16 {
+		t.Errorf("week = %d/%d, want 2026/16", m.Year(), m.Week())
+	}
+	if task, _ := m.GetSelectedTask(Tuesday); task.ID != "pinned" {
+		t.Errorf("selected task = %s, want pinned", task.ID)
+	}
+}
@@ -0,0 +1,184 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+	"github.com/rwirdemann/scheduled/week"
+)
+
+type repository interface {
+	LoadContexts() ([]scheduled.Context, error)
+	LoadTasks() ([]scheduled.Task, error)
+	SaveContexts(contexts []scheduled.Context) error
+	SaveTasks(tasks []scheduled.Task) error
+	LoadArchive() ([]scheduled.ArchivedTask, error)
+	SaveArchive(archive []scheduled.ArchivedTask) error
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(c *cli, fs *flag.FlagSet, args []string) error
+}
+
+var commands = []command{
+	{"add", "add [-day day] [-context name] [-desc text] [-pin] [-json] name", runAdd},
+	{"list", "list [-day day] [-context name] [-open] [-json]", runList},
+	{"done", "done [-undo] [-json] task", runDone},
+	{"move", "move [-json] task day", runMove},
+	{"rm", "rm [-force] [-json] task", runRemove},
+	{"contexts", "contexts [-json] | contexts add name | contexts rm name", runContexts},
+	{"serve", "serve [-addr host:port]", runServe},
+	{"migrate", "migrate [-from tasks.json] [-force]", runMigrate},
+	{"log", "log [-json] task", runLog},
+	{"export", "export [-format todotxt|ics] [-o file]", runExport},
+	{"import", "import file", runImport},
+	{"report", "report [-year year] [-week week] [-weeks n] [-template name] [-o file]", runReport},
+}
+
+var dayNames = []string{"Inbox", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// Usage writes the usage of all subcommands to w.
+func Usage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Commands:")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %s\n", cmd.usage)
+	}
+	_, _ = fmt.Fprintln(w, `
+Tasks are addressed by their position in the output of "list" or by a prefix
+of their ID. Days are inbox, mon ... sun, today, tomorrow or 0 ... 7. Import
+uses the todo.txt format, export writes todo.txt or iCalendar. Reports are
+Markdown unless a template is given.`)
+}
+
+// Run executes the subcommand args[0] with the remaining arguments on the
+// tasks and contexts of the repository and writes the result to out. Today is
+// taken from the clock.
+func Run(args []string, repository repository, clock date.Clock, out io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("no command given")
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			c := &cli{repository: repository, out: out, clock: clock, now: clock.Now()}
+			if err := c.load(); err != nil {
+				return err
+			}
+			return cmd.run(c, newFlagSet(cmd), args[1:])
+		}
+	}
+	return fmt.Errorf("unknown command '%s'", args[0])
+}
+
+// cli holds the tasks and contexts a subcommand works on.
+type cli struct {
+	repository repository
+	out        io.Writer
+	clock      date.Clock
+	now        time.Time
+	week       *week.Week
+	contexts   []scheduled.Context
+}
+
+func (c *cli) load() error {
+	tasks, err := c.repository.LoadTasks()
+	if err != nil {
+		return err
+	}
+	if c.contexts, err = c.repository.LoadContexts(); err != nil {
+		return err
+	}
+	c.week = week.New(tasks, c.now)
+	return nil
+}
+
+func (c *cli) saveTasks() error {
+	return c.repository.SaveTasks(c.week.Tasks)
+}
+
+// parseDay parses inbox, mon ... sun, today, tomorrow or 0 ... 7.
+func (c *cli) parseDay(s string) (int, error) {
+	s = strings.ToLower(s)
+	switch s {
+	case "today":
+		return scheduled.Weekday(c.now), nil
+	case "tomorrow":
+		return scheduled.Weekday(c.now.AddDate(0, 0, 1)), nil
+	}
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 && n <= 7 {
+		return n, nil
+	}
+	if len(s) >= 2 {
+		for day, name := range dayNames {
+			if strings.HasPrefix(strings.ToLower(name), s) {
+				return day, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid day '%s'", s)
+}
+
+func (c *cli) contextByName(name string) (scheduled.Context, error) {
+	for _, ctx := range c.contexts {
+		if strings.EqualFold(ctx.Name, name) {
+			return ctx, nil
+		}
+	}
+	return scheduled.Context{}, fmt.Errorf("context '%s' not found", name)
+}
+
+func (c *cli) contextName(id int) string {
+	for _, ctx := range c.contexts {
+		if ctx.ID == id {
+			return ctx.Name
+		}
+	}
+	return "unknown"
+}
+
+func (c *cli) dayName(t scheduled.Task) string {
+	if t.MultiDay() {
+		return "Weekdays"
+	}
+	if t.Day < 0 || t.Day >= len(dayNames) {
+		return "unknown"
+	}
+	return dayNames[t.Day]
+}
+
+func (c *cli) printJSON(v any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+func (c *cli) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(c.out, format, a...)
+}
+
+// shortID returns the first characters of the task ID, which are enough to
+// address the task in most cases.
+func shortID(t scheduled.Task) string {
+	if len(t.ID) > 8 {
+		return t.ID[:8]
+	}
+	return t.ID
+}
+
+func newFlagSet(cmd command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.name, flag.ContinueOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(fs.Output(), "usage: scheduled %s\n", cmd.usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
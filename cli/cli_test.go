@@ -0,0 +1,344 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+	"github.com/rwirdemann/scheduled/file"
+	"github.com/rwirdemann/scheduled/sqlite"
+)
+
+// testClock is Wednesday of week 10 in 2026.
+var testClock = date.Fixed(time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local))
+
+// Mock repository for testing
+type mockRepository struct {
+	tasks    []scheduled.Task
+	contexts []scheduled.Context
+	archive  []scheduled.ArchivedTask
+
+	// saveErr is returned by SaveTasks if set
+	saveErr error
+}
+
+func (m *mockRepository) LoadTasks() ([]scheduled.Task, error) {
+	return append([]scheduled.Task(nil), m.tasks...), nil
+}
+
+func (m *mockRepository) SaveTasks(tasks []scheduled.Task) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.tasks = tasks
+	return nil
+}
+
+func (m *mockRepository) LoadContexts() ([]scheduled.Context, error) {
+	return m.contexts, nil
+}
+
+func (m *mockRepository) SaveContexts(contexts []scheduled.Context) error {
+	m.contexts = contexts
+	return nil
+}
+
+func (m *mockRepository) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	return m.archive, nil
+}
+
+func (m *mockRepository) SaveArchive(archive []scheduled.ArchivedTask) error {
+	m.archive = archive
+	return nil
+}
+
+func newTestRepository() *mockRepository {
+	return &mockRepository{
+		tasks: []scheduled.Task{
+			{ID: "aaaa1111", Name: "Inbox task", Day: 0},
+			{ID: "bbbb2222", Name: "Second", Day: 2, Pos: 1},
+			{ID: "bbbb3333", Name: "First", Day: 2, Pos: 0},
+		},
+		contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}},
+	}
+}
+
+func run(t *testing.T, repo *mockRepository, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := Run(args, repo, testClock, &out); err != nil {
+		t.Fatalf("Run(%v) error = %v", args, err)
+	}
+	return out.String()
+}
+
+func TestRun_List(t *testing.T) {
+	repo := newTestRepository()
+
+	var listed []listedTask
+	if err := json.Unmarshal([]byte(run(t, repo, "list", "-json")), &listed); err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, l := range listed {
+		names = append(names, l.Name)
+	}
+	if got := strings.Join(names, ","); got != "Inbox task,First,Second" {
+		t.Errorf("list order = %s, want board order", got)
+	}
+	if listed[1].Position != 2 {
+		t.Errorf("Position = %d, want 2", listed[1].Position)
+	}
+}
+
+func TestRun_Add(t *testing.T) {
+	repo := newTestRepository()
+
+	out := run(t, repo, "add", "-day", "tue", "-context", "work", "Write", "report")
+	if !strings.Contains(out, "Added 'Write report' to Tuesday") {
+		t.Errorf("output = %q", out)
+	}
+
+	added := repo.tasks[len(repo.tasks)-1]
+	if added.Day != 2 || added.Context != 2 || added.Pos != 2 {
+		t.Errorf("added task = %+v", added)
+	}
+}
+
+func TestRun_AddTodayUsesClock(t *testing.T) {
+	repo := newTestRepository()
+
+	out := run(t, repo, "add", "-day", "tomorrow", "Water plants")
+	if !strings.Contains(out, "to Thursday") {
+		t.Errorf("output = %q, want the task added to Thursday", out)
+	}
+}
+
+func TestRun_DoneByPositionAndPrefix(t *testing.T) {
+	repo := newTestRepository()
+
+	run(t, repo, "done", "1")
+	if !repo.tasks[0].Done {
+		t.Error("Inbox task should be done")
+	}
+
+	run(t, repo, "done", "bbbb3")
+	first := repo.tasks[2]
+	if first.Done || len(first.DoneWeeks) != 1 {
+		t.Errorf("rolling task should be done for the current week only, got %+v", first)
+	}
+
+	var out bytes.Buffer
+	if err := Run([]string{"done", "bbbb"}, repo, testClock, &out); err == nil {
+		t.Error("ambiguous ID prefix should fail")
+	}
+}
+
+func TestRun_MoveAndRemove(t *testing.T) {
+	repo := newTestRepository()
+
+	run(t, repo, "move", "aaaa", "fri")
+	if repo.tasks[0].Day != 5 {
+		t.Errorf("Day = %d, want 5", repo.tasks[0].Day)
+	}
+
+	var out bytes.Buffer
+	if err := Run([]string{"rm", "aaaa"}, repo, testClock, &out); err == nil {
+		t.Error("removing an open task should fail without -force")
+	}
+
+	run(t, repo, "rm", "-force", "aaaa")
+	if len(repo.tasks) != 2 {
+		t.Errorf("Expected 2 tasks after removal, got %d", len(repo.tasks))
+	}
+	if len(repo.archive) != 0 {
+		t.Errorf("archive = %v, open tasks should not be archived", repo.archive)
+	}
+
+	// Done tasks are archived as completed when they were checked
+	completed := time.Date(2026, time.March, 2, 18, 15, 0, 0, time.Local)
+	repo.tasks[0].DoneWeeks = []string{"2026-W10"}
+	repo.tasks[0].Completed = completed
+	repo.saveErr = scheduled.ErrModified
+	if err := Run([]string{"rm", repo.tasks[0].ID}, repo, testClock, &out); err == nil || len(repo.archive) != 0 {
+		t.Fatalf("rm = %v, archive = %v, want nothing archived if saving fails", err, repo.archive)
+	}
+	repo.saveErr = nil
+	run(t, repo, "rm", repo.tasks[0].ID)
+	if len(repo.tasks) != 1 || len(repo.archive) != 1 {
+		t.Fatalf("tasks = %v, archive = %v, want the done task archived", repo.tasks, repo.archive)
+	}
+	if a := repo.archive[0]; a.Task.ID != "bbbb2222" || !a.Completed.Equal(completed) {
+		t.Errorf("archived %+v, want bbbb2222 completed at %v", a, completed)
+	}
+}
+
+func TestRun_Contexts(t *testing.T) {
+	repo := newTestRepository()
+
+	run(t, repo, "contexts", "add", "home")
+	if out := run(t, repo, "contexts"); out != "none\nwork\nhome\n" {
+		t.Errorf("contexts output = %q", out)
+	}
+}
+
+func TestRun_Log(t *testing.T) {
+	repo, err := sqlite.Open(filepath.Join(t.TempDir(), "tasks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	var out bytes.Buffer
+	for _, args := range [][]string{{"add", "Task"}, {"done", "1"}, {"log", "-json", "1"}} {
+		out.Reset()
+		if err := Run(args, repo, testClock, &out); err != nil {
+			t.Fatalf("Run(%v) error = %v", args, err)
+		}
+	}
+
+	var changes []sqlite.Change
+	if err := json.Unmarshal(out.Bytes(), &changes); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Action != sqlite.ActionCreated || !changes[1].Task.Done {
+		t.Errorf("changes = %+v, want creation and completion", changes)
+	}
+}
+
+func TestRun_Report(t *testing.T) {
+	defer func(dir string) { templateDir = dir }(templateDir)
+	templateDir = t.TempDir()
+	repo := newTestRepository()
+	repo.tasks[1].DoneWeeks = []string{"2026-W10"}
+
+	got := run(t, repo, "report")
+	for _, line := range []string{"# Week 10, 2026\n", "## Tuesday, March 3 (1/2)\n", "- [ ] First\n", "- [x] Second\n"} {
+		if !strings.Contains(got, line) {
+			t.Errorf("report = %q, want it to contain %q", got, line)
+		}
+	}
+	if strings.Contains(got, "Inbox task") {
+		t.Errorf("report = %q, want no Inbox tasks", got)
+	}
+
+	if err := os.WriteFile(filepath.Join(templateDir, "count.tmpl"), []byte("{{range .Weeks}}{{.Week}}:{{.Done}}/{{.Total}} {{end}}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(t, repo, "report", "-week", "9", "-weeks", "3", "-template", "count"); got != "9:0/2 10:1/2 11:0/2 " {
+		t.Errorf("report = %q, want counts of weeks 9 to 11", got)
+	}
+
+	var out bytes.Buffer
+	if err := Run([]string{"report", "-week", "54"}, repo, testClock, &out); err == nil {
+		t.Error("report should fail for invalid weeks")
+	}
+}
+
+func TestRun_Migrate(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"tasks.json":         `{"version": 1, "tasks": [{"id": "1", "name": "Task", "day": 2}]}`,
+		"tasks.archive.json": `{"version": 1, "archive": [{"task": {"id": "2", "name": "Done", "done": true}, "completed": "2026-03-03T18:00:00Z"}]}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Task files are named relative to the directory of file.Path
+	from, err := filepath.Rel(file.Path(""), filepath.Join(dir, "tasks.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo, err := sqlite.Open(filepath.Join(dir, "tasks.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = repo.Close() }()
+
+	var out bytes.Buffer
+	if err := Run([]string{"migrate", "-from", from}, repo, testClock, &out); err != nil {
+		t.Fatal(err)
+	}
+	if want := "Imported 1 tasks, 0 contexts and 1 archived tasks from " + from + "\n"; out.String() != want {
+		t.Errorf("migrate = %q, want %q", out.String(), want)
+	}
+	if archive, err := repo.LoadArchive(); err != nil || len(archive) != 1 || archive[0].Task.ID != "2" {
+		t.Errorf("LoadArchive() = %v, %v, want the archived task", archive, err)
+	}
+
+	// An archive alone keeps the target from being replaced
+	if err := repo.SaveTasks(nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := Run([]string{"migrate", "-from", from}, repo, testClock, &out); err == nil {
+		t.Error("migrate should fail if the target has archived tasks")
+	}
+}
+
+func TestRun_LogRequiresHistory(t *testing.T) {
+	var out bytes.Buffer
+	if err := Run([]string{"log", "1"}, newTestRepository(), testClock, &out); err == nil {
+		t.Error("log should fail for repositories without history")
+	}
+}
+
+func TestRun_ExportAndImport(t *testing.T) {
+	repo := newTestRepository()
+	repo.tasks[0].Context = 2
+
+	want := "Inbox task @work id:aaaa1111\nFirst due:2026-03-03 id:bbbb3333\nSecond due:2026-03-03 id:bbbb2222\n"
+	exported := run(t, repo, "export")
+	if exported != want {
+		t.Errorf("export = %q, want %q", exported, want)
+	}
+
+	// Importing the export again updates the tasks
+	filename := filepath.Join(t.TempDir(), "todo.txt")
+	if err := os.WriteFile(filename, []byte(strings.Replace(exported, "First", "x First", 1)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(t, repo, "import", filename); !strings.Contains(got, "0 new and 3 updated") {
+		t.Errorf("import = %q", got)
+	}
+	if len(repo.tasks) != 3 {
+		t.Fatalf("tasks = %v, want no duplicates", repo.tasks)
+	}
+	if got := run(t, repo, "list", "-day", "tue"); !strings.Contains(got, "[x]") {
+		t.Errorf("list = %q, want First done", got)
+	}
+
+	if err := os.WriteFile(filename, []byte("x Call Anna @family due:2026-03-06\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(t, repo, "import", filename); !strings.Contains(got, "Imported 1 tasks") {
+		t.Errorf("import = %q", got)
+	}
+	if got := run(t, repo, "list", "-day", "fri"); !strings.Contains(got, "[x]") || !strings.Contains(got, "family") {
+		t.Errorf("list = %q, want the imported task done on Friday in context family", got)
+	}
+}
+
+func TestRun_ExportCalendar(t *testing.T) {
+	got := run(t, newTestRepository(), "export", "-format", "ics")
+	for _, line := range []string{"BEGIN:VCALENDAR", "SUMMARY:First", "DTSTART;VALUE=DATE:20260303", "RRULE:FREQ=WEEKLY;BYDAY=TU"} {
+		if !strings.Contains(got, line+"\r\n") {
+			t.Errorf("export = %q, want it to contain %s", got, line)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := Run([]string{"export", "-format", "xml"}, newTestRepository(), testClock, &out); err == nil {
+		t.Error("export should fail for unknown formats")
+	}
+}
@@ -0,0 +1,497 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+	"github.com/rwirdemann/scheduled/file"
+	"github.com/rwirdemann/scheduled/ical"
+	"github.com/rwirdemann/scheduled/report"
+	"github.com/rwirdemann/scheduled/server"
+	"github.com/rwirdemann/scheduled/sqlite"
+	"github.com/rwirdemann/scheduled/todotxt"
+)
+
+// listedTask is a task as printed by list, together with its position.
+type listedTask struct {
+	Position int `json:"position"`
+	scheduled.Task
+}
+
+func runAdd(c *cli, fs *flag.FlagSet, args []string) error {
+	day := fs.String("day", "inbox", "day to schedule the task for")
+	context := fs.String("context", scheduled.ContextNone.Name, "context of the task")
+	desc := fs.String("desc", "", "description of the task")
+	pin := fs.Bool("pin", false, "pin the task to the date of the day in the current week")
+	asJSON := fs.Bool("json", false, "print the task as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	name := strings.Join(fs.Args(), " ")
+	if name == "" {
+		return errors.New("please enter a title")
+	}
+	d, err := c.parseDay(*day)
+	if err != nil {
+		return err
+	}
+	ctx, err := c.contextByName(*context)
+	if err != nil {
+		return err
+	}
+
+	t := scheduled.Task{Name: name, Desc: *desc, Day: d, Context: ctx.ID}
+	if *pin {
+		if d == 0 {
+			return errors.New("Inbox tasks can not be pinned")
+		}
+		t.Pin(c.week.DateOf(d))
+	}
+
+	t = c.week.Add(t)
+	if err := c.saveTasks(); err != nil {
+		return err
+	}
+	if *asJSON {
+		return c.printJSON(t)
+	}
+	c.printf("Added '%s' to %s (%s)\n", t.Name, c.dayName(t), shortID(t))
+	return nil
+}
+
+func runList(c *cli, fs *flag.FlagSet, args []string) error {
+	day := fs.String("day", "", "only list tasks of this day")
+	context := fs.String("context", "", "only list tasks of this context")
+	open := fs.Bool("open", false, "only list tasks that are not done")
+	asJSON := fs.Bool("json", false, "print the tasks as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	dayFilter := -1
+	if *day != "" {
+		d, err := c.parseDay(*day)
+		if err != nil {
+			return err
+		}
+		dayFilter = d
+	}
+	contextFilter := -1
+	if *context != "" {
+		ctx, err := c.contextByName(*context)
+		if err != nil {
+			return err
+		}
+		contextFilter = ctx.ID
+	}
+
+	listed := []listedTask{}
+	for n, i := range c.week.View() {
+		t := c.week.Task(i)
+		if dayFilter >= 0 && t.Day != dayFilter && !(t.MultiDay() && t.OccursOn(c.week.DateOf(dayFilter))) {
+			continue
+		}
+		if contextFilter >= 0 && t.Context != contextFilter {
+			continue
+		}
+		if *open && t.Done {
+			continue
+		}
+		listed = append(listed, listedTask{Position: n + 1, Task: t})
+	}
+
+	if *asJSON {
+		return c.printJSON(listed)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	for _, l := range listed {
+		check := "[ ]"
+		if l.Done {
+			check = "[x]"
+		}
+		_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", l.Position, check, c.dayName(l.Task), l.Name, c.contextName(l.Context), shortID(l.Task))
+	}
+	return w.Flush()
+}
+
+func runDone(c *cli, fs *flag.FlagSet, args []string) error {
+	undo := fs.Bool("undo", false, "mark the task as not done")
+	asJSON := fs.Bool("json", false, "print the task as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please name exactly one task")
+	}
+
+	i, err := c.week.Find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	c.week.SetDone(i, !*undo)
+	if err := c.saveTasks(); err != nil {
+		return err
+	}
+	t := c.week.Task(i)
+	if *asJSON {
+		return c.printJSON(t)
+	}
+	if *undo {
+		c.printf("Marked '%s' as not done\n", t.Name)
+	} else {
+		c.printf("Marked '%s' as done\n", t.Name)
+	}
+	return nil
+}
+
+func runMove(c *cli, fs *flag.FlagSet, args []string) error {
+	asJSON := fs.Bool("json", false, "print the task as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return errors.New("please name a task and a day")
+	}
+
+	i, err := c.week.Find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	day, err := c.parseDay(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	if err := c.week.Move(i, day); err != nil {
+		return err
+	}
+	if err := c.saveTasks(); err != nil {
+		return err
+	}
+	t := c.week.Task(i)
+	if *asJSON {
+		return c.printJSON(t)
+	}
+	c.printf("Moved '%s' to %s\n", t.Name, c.dayName(t))
+	return nil
+}
+
+func runRemove(c *cli, fs *flag.FlagSet, args []string) error {
+	force := fs.Bool("force", false, "remove the task even if it is not done")
+	asJSON := fs.Bool("json", false, "print the removed task as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please name exactly one task")
+	}
+
+	i, err := c.week.Find(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	t := c.week.Task(i)
+	if !t.Done && !*force {
+		return fmt.Errorf("'%s' is not done, use -force to remove it anyway", t.Name)
+	}
+
+	c.week.Remove(i)
+	if err := c.saveTasks(); err != nil {
+		return err
+	}
+
+	// Done tasks are archived once they are removed, so that a failed save
+	// doesn't archive them twice
+	if t.Done {
+		archive, err := c.repository.LoadArchive()
+		if err != nil {
+			return err
+		}
+		if err := c.repository.SaveArchive(append(archive, scheduled.Archive(t, c.now))); err != nil {
+			return err
+		}
+	}
+	if *asJSON {
+		return c.printJSON(t)
+	}
+	c.printf("Removed '%s'\n", t.Name)
+	return nil
+}
+
+func runContexts(c *cli, fs *flag.FlagSet, args []string) error {
+	asJSON := fs.Bool("json", false, "print the contexts as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "":
+		if *asJSON {
+			return c.printJSON(c.contexts)
+		}
+		for _, ctx := range c.contexts {
+			c.printf("%s\n", ctx.Name)
+		}
+		return nil
+	case "add":
+		name := strings.Join(fs.Args()[1:], " ")
+		if name == "" {
+			return errors.New("Context must not be empty")
+		}
+		maxID := 1
+		for _, ctx := range c.contexts {
+			if strings.EqualFold(ctx.Name, name) {
+				return fmt.Errorf("Context '%s' does already exist", name)
+			}
+			maxID = max(maxID, ctx.ID)
+		}
+		ctx := scheduled.Context{ID: maxID + 1, Name: name}
+		c.contexts = append(c.contexts, ctx)
+		if err := c.repository.SaveContexts(c.contexts); err != nil {
+			return err
+		}
+		if *asJSON {
+			return c.printJSON(ctx)
+		}
+		c.printf("Added context '%s'\n", ctx.Name)
+		return nil
+	case "rm":
+		ctx, err := c.contextByName(strings.Join(fs.Args()[1:], " "))
+		if err != nil {
+			return err
+		}
+		if ctx.ID == scheduled.ContextNone.ID {
+			return fmt.Errorf("Context '%s' can not be deleted", scheduled.ContextNone.Name)
+		}
+		for _, t := range c.week.Tasks {
+			if t.Context == ctx.ID {
+				return fmt.Errorf("Context '%s' is beeing used", ctx.Name)
+			}
+		}
+		c.contexts = slices.DeleteFunc(c.contexts, func(other scheduled.Context) bool {
+			return other.ID == ctx.ID
+		})
+		if err := c.repository.SaveContexts(c.contexts); err != nil {
+			return err
+		}
+		if *asJSON {
+			return c.printJSON(ctx)
+		}
+		c.printf("Removed context '%s'\n", ctx.Name)
+		return nil
+	}
+	return fmt.Errorf("unknown contexts command '%s'", fs.Arg(0))
+}
+
+func runServe(c *cli, fs *flag.FlagSet, args []string) error {
+	addr := fs.String("addr", "localhost:7070", "address to serve the API on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           server.New(c.repository, c.clock),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+	c.printf("Serving tasks on http://%s, press ctrl+c to stop\n", *addr)
+	return srv.ListenAndServe()
+}
+
+func runMigrate(c *cli, fs *flag.FlagSet, args []string) error {
+	from := fs.String("from", "tasks.json", "tasks file to import, its contexts and archive files are imported as well")
+	force := fs.Bool("force", false, "replace existing tasks, contexts and archive")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if _, ok := c.repository.(file.Repository); ok {
+		return errors.New("please choose the target with -store, e.g. scheduled -store sqlite migrate")
+	}
+	archived, err := c.repository.LoadArchive()
+	if err != nil {
+		return err
+	}
+	if (len(c.week.Tasks) > 0 || len(c.contexts) > 1 || len(archived) > 0) && !*force {
+		return errors.New("target already contains tasks, contexts or archived tasks, use -force to replace them")
+	}
+
+	source := file.NewRepository(*from, c.clock)
+	tasks, err := source.LoadTasks()
+	if err != nil {
+		return err
+	}
+	contexts, err := source.LoadContexts()
+	if err != nil {
+		return err
+	}
+	archive, err := source.LoadArchive()
+	if err != nil {
+		return err
+	}
+	if err := c.repository.SaveContexts(contexts); err != nil {
+		return err
+	}
+	if err := c.repository.SaveTasks(tasks); err != nil {
+		return err
+	}
+	if err := c.repository.SaveArchive(archive); err != nil {
+		return err
+	}
+	c.printf("Imported %d tasks, %d contexts and %d archived tasks from %s\n", len(tasks), len(contexts)-1, len(archive), *from)
+	return nil
+}
+
+// taskHistory is implemented by repositories that record the changes of each
+// task, like sqlite.Repository.
+type taskHistory interface {
+	TaskHistory(id string) ([]sqlite.Change, error)
+}
+
+func runLog(c *cli, fs *flag.FlagSet, args []string) error {
+	asJSON := fs.Bool("json", false, "print the changes as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please name exactly one task")
+	}
+	h, ok := c.repository.(taskHistory)
+	if !ok {
+		return errors.New("task history is only recorded with -store sqlite")
+	}
+
+	// Deleted tasks can still be addressed by their full ID
+	id := fs.Arg(0)
+	if i, err := c.week.Find(id); err == nil {
+		id = c.week.Tasks[i].ID
+	}
+	changes, err := h.TaskHistory(id)
+	if err != nil {
+		return err
+	}
+	if len(changes) == 0 {
+		return fmt.Errorf("task '%s' not found", fs.Arg(0))
+	}
+
+	if *asJSON {
+		return c.printJSON(changes)
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	for _, change := range changes {
+		t := change.Task
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", change.Time.Format("2006-01-02 15:04"), change.Action, t.Name, c.dayName(t), c.contextName(t.Context))
+	}
+	return w.Flush()
+}
+
+func runExport(c *cli, fs *flag.FlagSet, args []string) error {
+	output := fs.String("o", "", "file to write to instead of the standard output")
+	format := fs.String("format", "todotxt", "todotxt or ics")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var text string
+	switch *format {
+	case "todotxt":
+		text = todotxt.Format(c.week, c.contexts)
+	case "ics":
+		text = ical.Format(c.week, c.contexts, c.now)
+	default:
+		return fmt.Errorf("unknown format '%s'", *format)
+	}
+	if *output == "" {
+		c.printf("%s", text)
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(text), 0644); err != nil {
+		return err
+	}
+	c.printf("Exported %d tasks to %s\n", len(c.week.View()), *output)
+	return nil
+}
+
+// templateDir is the directory user-defined report templates are read from.
+var templateDir = file.Path("templates")
+
+func runReport(c *cli, fs *flag.FlagSet, args []string) error {
+	year, week := c.now.ISOWeek()
+	fs.IntVar(&year, "year", year, "year of the first week")
+	fs.IntVar(&week, "week", week, "first week of the report")
+	weeks := fs.Int("weeks", 1, "number of weeks")
+	name := fs.String("template", "", "template in "+templateDir+" instead of the built-in Markdown")
+	output := fs.String("o", "", "file to write to instead of the standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if week < 1 || week > date.WeeksInYear(year) {
+		return fmt.Errorf("invalid week %d of %d", week, year)
+	}
+	if *weeks < 1 {
+		return fmt.Errorf("invalid number of weeks %d", *weeks)
+	}
+
+	tmpl, err := report.Load(templateDir, *name)
+	if err != nil {
+		return err
+	}
+	var b strings.Builder
+	if err := report.Render(&b, report.New(c.week.Tasks, c.contexts, year, week, *weeks), tmpl); err != nil {
+		return err
+	}
+	if *output == "" {
+		c.printf("%s", b.String())
+		return nil
+	}
+	if err := os.WriteFile(*output, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	c.printf("Wrote report to %s\n", *output)
+	return nil
+}
+
+func runImport(c *cli, fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("please name exactly one file")
+	}
+
+	data, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	tasks, contexts, err := todotxt.Parse(string(data), c.week, c.contexts)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fs.Arg(0), err)
+	}
+	if len(contexts) > len(c.contexts) {
+		c.contexts = contexts
+		if err := c.repository.SaveContexts(c.contexts); err != nil {
+			return err
+		}
+	}
+	updated := todotxt.Import(c.week, tasks)
+	if err := c.saveTasks(); err != nil {
+		return err
+	}
+	c.printf("Imported %d tasks from %s, %d new and %d updated\n", len(tasks), fs.Arg(0), len(tasks)-updated, updated)
+	return nil
+}
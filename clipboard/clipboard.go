@@ -2,12 +2,62 @@ package clipboard
 
 import (
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 
 	"github.com/rwirdemann/scheduled"
 )
 
+// Formatter formats a list of tasks into a string suitable for copying to the
+// clipboard.
+type Formatter func(contexts []scheduled.Context, tasks []scheduled.Task) string
+
+type format struct {
+	name      string
+	formatter Formatter
+}
+
+// formats are the registered formats in the order they are offered.
+var formats = []format{
+	{"summary", FormatTasks},
+	{"plain", formatPlain},
+	{"markdown", formatMarkdown},
+	{"slack", formatSlack},
+	{"html", formatHTML},
+	{"csv", formatCSV},
+	{"json", formatJSON},
+}
+
+// Register adds a format of the given name, or replaces the format if the
+// name is already registered.
+func Register(name string, f Formatter) {
+	if i := slices.IndexFunc(formats, func(f format) bool { return f.name == name }); i >= 0 {
+		formats[i].formatter = f
+		return
+	}
+	formats = append(formats, format{name, f})
+}
+
+// Formats returns the names of the registered formats.
+func Formats() []string {
+	names := make([]string, len(formats))
+	for i, f := range formats {
+		names[i] = f.name
+	}
+	return names
+}
+
+// Format formats the tasks in the format of the given name.
+func Format(name string, contexts []scheduled.Context, tasks []scheduled.Task) (string, error) {
+	for _, f := range formats {
+		if f.name == name {
+			return f.formatter(contexts, tasks), nil
+		}
+	}
+	return "", fmt.Errorf("unknown format '%s'", name)
+}
+
 // FormatTasks formats a list of tasks into a string suitable for copying to the
 // clipboard.
 func FormatTasks(contexts []scheduled.Context, tasks []scheduled.Task) string {
@@ -27,15 +77,6 @@ func FormatTasks(contexts []scheduled.Context, tasks []scheduled.Task) string {
 	// Build the output string
 	var result []string
 	for _, contextID := range contextIDs {
-		// Find context name
-		contextName := "Unknown"
-		for _, ctx := range contexts {
-			if ctx.ID == contextID {
-				contextName = ctx.Name
-				break
-			}
-		}
-
 		// Get task names
 		taskNames := make([]string, 0, len(tasksByContext[contextID]))
 		for _, task := range tasksByContext[contextID] {
@@ -43,9 +84,19 @@ func FormatTasks(contexts []scheduled.Context, tasks []scheduled.Task) string {
 		}
 
 		// Format: "ContextName: Task1, Task2"
-		result = append(result, fmt.Sprintf("%s: %s", contextName, strings.Join(taskNames, ", ")))
+		result = append(result, fmt.Sprintf("%s: %s", contextName(contexts, contextID), strings.Join(taskNames, ", ")))
 	}
 
 	// Join with semicolons
 	return strings.Join(result, "; ")
 }
+
+// contextName returns the name of the context with the given ID.
+func contextName(contexts []scheduled.Context, id int) string {
+	for _, ctx := range contexts {
+		if ctx.ID == id {
+			return ctx.Name
+		}
+	}
+	return "Unknown"
+}
@@ -0,0 +1,72 @@
+package clipboard
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+var contexts = []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}}
+
+var tuesday = []scheduled.Task{
+	{Name: "Write report", Day: 2, Context: 2, Done: true, Desc: "Chapter 1"},
+	{Name: "Milk & eggs", Day: 2, Context: 1},
+}
+
+var week = []scheduled.Task{
+	{Name: "Plan trip", Day: 0, Context: 1},
+	{Name: "Write report", Day: 2, Context: 2, Done: true},
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		format string
+		tasks  []scheduled.Task
+		want   string
+	}{
+		{"summary", tuesday, "none: Milk & eggs; work: Write report"},
+		{"plain", tuesday, "Write report\nMilk & eggs\n"},
+		{"markdown", tuesday, "- [x] Write report\n- [ ] Milk & eggs\n"},
+		{"markdown", week, "## Inbox\n\n- [ ] Plan trip\n\n## Tuesday\n\n- [x] Write report\n"},
+		{"slack", tuesday, "• ~Write report~\n• Milk &amp; eggs\n"},
+		{"slack", []scheduled.Task{{Name: "Ping <!channel> if > 5"}}, "• Ping &lt;!channel&gt; if &gt; 5\n"},
+		{"slack", week, "*Inbox*\n• Plan trip\n*Tuesday*\n• ~Write report~\n"},
+		{"html", tuesday, "<ul>\n  <li><s>Write report</s></li>\n  <li>Milk &amp; eggs</li>\n</ul>\n"},
+		{"html", week, "<h2>Inbox</h2>\n<ul>\n  <li>Plan trip</li>\n</ul>\n<h2>Tuesday</h2>\n<ul>\n  <li><s>Write report</s></li>\n</ul>\n"},
+		{"csv", tuesday, "name,day,context,done,description\nWrite report,Tuesday,work,true,Chapter 1\nMilk & eggs,Tuesday,none,false,\n"},
+		{"json", tuesday[:1], "[\n  {\n    \"name\": \"Write report\",\n    \"day\": \"Tuesday\",\n    \"context\": \"work\",\n    \"done\": true,\n    \"description\": \"Chapter 1\"\n  }\n]\n"},
+		{"json", nil, "[]\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			got, err := Format(tt.format, contexts, tt.tasks)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Format("xml", contexts, tuesday); err == nil {
+		t.Error("Format() should fail for unknown formats")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	defer func(registered []format) { formats = registered }(slices.Clone(formats))
+
+	Register("count", func(contexts []scheduled.Context, tasks []scheduled.Task) string { return "2 tasks" })
+	Register("plain", func(contexts []scheduled.Context, tasks []scheduled.Task) string { return "replaced" })
+
+	names := Formats()
+	if names[len(names)-1] != "count" || slices.Index(names, "plain") != 1 {
+		t.Errorf("Formats() = %v, want count appended and plain replaced in place", names)
+	}
+	if got, _ := Format("plain", contexts, tuesday); got != "replaced" {
+		t.Errorf("Format(plain) = %q, want the replaced format", got)
+	}
+}
@@ -0,0 +1,143 @@
+package clipboard
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+var dayNames = []string{"Inbox", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// slackEscaper escapes the characters that Slack reserves for its control
+// sequences like <@user> in mrkdwn.
+var slackEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// section holds the consecutive tasks of a day.
+type section struct {
+	day   string
+	tasks []scheduled.Task
+}
+
+// sections groups the tasks by day. Formats show the names of the days only
+// if there is more than one section, e.g. when copying the whole week.
+func sections(tasks []scheduled.Task) []section {
+	var result []section
+	for _, t := range tasks {
+		day := dayNames[t.Day]
+		if len(result) == 0 || result[len(result)-1].day != day {
+			result = append(result, section{day: day})
+		}
+		result[len(result)-1].tasks = append(result[len(result)-1].tasks, t)
+	}
+	return result
+}
+
+// formatPlain writes the name of each task on a line.
+func formatPlain(contexts []scheduled.Context, tasks []scheduled.Task) string {
+	var b strings.Builder
+	for _, t := range tasks {
+		b.WriteString(t.Name + "\n")
+	}
+	return b.String()
+}
+
+// formatMarkdown writes a checklist with a heading per day.
+func formatMarkdown(contexts []scheduled.Context, tasks []scheduled.Task) string {
+	var b strings.Builder
+	days := sections(tasks)
+	for i, s := range days {
+		if len(days) > 1 {
+			if i > 0 {
+				b.WriteString("\n")
+			}
+			b.WriteString("## " + s.day + "\n\n")
+		}
+		for _, t := range s.tasks {
+			check := " "
+			if t.Done {
+				check = "x"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", check, t.Name)
+		}
+	}
+	return b.String()
+}
+
+// formatSlack writes a bulleted list in Slack's mrkdwn with done tasks struck
+// through and a bold line per day.
+func formatSlack(contexts []scheduled.Context, tasks []scheduled.Task) string {
+	var b strings.Builder
+	days := sections(tasks)
+	for _, s := range days {
+		if len(days) > 1 {
+			b.WriteString("*" + s.day + "*\n")
+		}
+		for _, t := range s.tasks {
+			name := slackEscaper.Replace(t.Name)
+			if t.Done {
+				name = "~" + name + "~"
+			}
+			b.WriteString("• " + name + "\n")
+		}
+	}
+	return b.String()
+}
+
+// formatHTML writes an unordered list with done tasks struck through and a
+// heading per day.
+func formatHTML(contexts []scheduled.Context, tasks []scheduled.Task) string {
+	var b strings.Builder
+	days := sections(tasks)
+	for _, s := range days {
+		if len(days) > 1 {
+			b.WriteString("<h2>" + s.day + "</h2>\n")
+		}
+		b.WriteString("<ul>\n")
+		for _, t := range s.tasks {
+			name := html.EscapeString(t.Name)
+			if t.Done {
+				name = "<s>" + name + "</s>"
+			}
+			b.WriteString("  <li>" + name + "</li>\n")
+		}
+		b.WriteString("</ul>\n")
+	}
+	return b.String()
+}
+
+// formatCSV writes a line per task with a header.
+func formatCSV(contexts []scheduled.Context, tasks []scheduled.Task) string {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	_ = w.Write([]string{"name", "day", "context", "done", "description"})
+	for _, t := range tasks {
+		_ = w.Write([]string{t.Name, dayNames[t.Day], contextName(contexts, t.Context), strconv.FormatBool(t.Done), t.Desc})
+	}
+	w.Flush()
+	return b.String()
+}
+
+// copiedTask is a task as copied in the JSON format.
+type copiedTask struct {
+	Name        string `json:"name"`
+	Day         string `json:"day"`
+	Context     string `json:"context"`
+	Done        bool   `json:"done"`
+	Description string `json:"description,omitempty"`
+}
+
+// formatJSON writes an array of tasks.
+func formatJSON(contexts []scheduled.Context, tasks []scheduled.Task) string {
+	copied := []copiedTask{}
+	for _, t := range tasks {
+		copied = append(copied, copiedTask{Name: t.Name, Day: dayNames[t.Day], Context: contextName(contexts, t.Context), Done: t.Done, Description: t.Desc})
+	}
+	data, _ := json.MarshalIndent(copied, "", "  ")
+	return string(data) + "\n"
+}
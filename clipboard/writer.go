@@ -0,0 +1,121 @@
+package clipboard
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	system "github.com/atotto/clipboard"
+)
+
+// Writer writes text to a clipboard.
+type Writer interface {
+	// Name describes where the text goes, e.g. in status messages.
+	Name() string
+	Write(text string) error
+}
+
+// System writes to the clipboard of the operating system, which requires
+// xclip, xsel or wl-copy on Linux.
+type System struct{}
+
+func (System) Name() string { return "system clipboard" }
+
+func (System) Write(text string) error {
+	if system.Unsupported {
+		return errors.New("no xclip, xsel or wl-copy found")
+	}
+	return system.WriteAll(text)
+}
+
+// OSC52 asks the terminal to set its clipboard with the OSC 52 escape
+// sequence, which also works over SSH. Inside GNU screen the sequence is
+// passed through to the outer terminal. tmux forwards it if set-clipboard is
+// enabled.
+type OSC52 struct {
+	Terminal io.Writer
+	Screen   bool
+}
+
+func (OSC52) Name() string { return "terminal clipboard (OSC 52)" }
+
+func (o OSC52) Write(text string) error {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if o.Screen {
+		seq = "\x1bP" + seq + "\x1b\\"
+	}
+	_, err := io.WriteString(o.Terminal, seq)
+	return err
+}
+
+// File writes the text to a file for sessions without any clipboard.
+type File struct {
+	Path string
+}
+
+func (f File) Name() string { return f.Path }
+
+func (f File) Write(text string) error {
+	return os.WriteFile(f.Path, []byte(text), 0600)
+}
+
+// Chain writes to the first writer that succeeds.
+type Chain []Writer
+
+// Write writes the text and returns the name of the writer used. If all
+// writers fail, the error lists the reasons.
+func (c Chain) Write(text string) (string, error) {
+	var reasons []string
+	for _, w := range c {
+		err := w.Write(text)
+		if err == nil {
+			return w.Name(), nil
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %v", w.Name(), err))
+	}
+	if len(reasons) == 0 {
+		return "", errors.New("no clipboard available")
+	}
+	return "", errors.New(strings.Join(reasons, "; "))
+}
+
+// Strategies are the names of the writers that can be selected explicitly.
+var Strategies = []string{"system", "osc52", "file"}
+
+// Auto chooses the writers from the environment given by getenv. Sessions
+// over SSH use the terminal, whose clipboard is the one of the user, all
+// others the system clipboard before the terminal. The terminal is skipped if
+// TERM is not set or dumb. The file is the last resort.
+func Auto(getenv func(string) string, terminal io.Writer, filename string) Chain {
+	osc52 := newOSC52(getenv, terminal)
+	var c Chain
+	switch {
+	case getenv("SSH_TTY") != "" || getenv("SSH_CONNECTION") != "":
+		c = Chain{osc52}
+	case getenv("TERM") == "" || getenv("TERM") == "dumb":
+		c = Chain{System{}}
+	default:
+		c = Chain{System{}, osc52}
+	}
+	return append(c, File{Path: filename})
+}
+
+// Select returns the writer of the given strategy, one of Strategies.
+func Select(name string, getenv func(string) string, terminal io.Writer, filename string) (Chain, error) {
+	switch name {
+	case "system":
+		return Chain{System{}}, nil
+	case "osc52":
+		return Chain{newOSC52(getenv, terminal)}, nil
+	case "file":
+		return Chain{File{Path: filename}}, nil
+	}
+	return nil, fmt.Errorf("unknown clipboard '%s'", name)
+}
+
+func newOSC52(getenv func(string) string, terminal io.Writer) OSC52 {
+	return OSC52{Terminal: terminal, Screen: getenv("TMUX") == "" && strings.HasPrefix(getenv("TERM"), "screen")}
+}
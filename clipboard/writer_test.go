@@ -0,0 +1,91 @@
+package clipboard
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func env(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestAuto(t *testing.T) {
+	var terminal bytes.Buffer
+	file := File{Path: "clipboard.txt"}
+	osc52 := OSC52{Terminal: &terminal}
+	tests := []struct {
+		name string
+		env  map[string]string
+		want Chain
+	}{
+		{"local terminal", map[string]string{"TERM": "xterm-256color"}, Chain{System{}, osc52, file}},
+		{"ssh", map[string]string{"TERM": "xterm-256color", "SSH_TTY": "/dev/pts/1"}, Chain{osc52, file}},
+		{"screen over ssh", map[string]string{"TERM": "screen", "SSH_CONNECTION": "10.0.0.1 22"}, Chain{OSC52{Terminal: &terminal, Screen: true}, file}},
+		{"tmux", map[string]string{"TERM": "screen", "TMUX": "/tmp/tmux"}, Chain{System{}, osc52, file}},
+		{"no terminal", map[string]string{"TERM": "dumb"}, Chain{System{}, file}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Auto(env(tt.env), &terminal, file.Path); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Auto() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	var terminal bytes.Buffer
+	c, err := Select("osc52", env(nil), &terminal, "clipboard.txt")
+	if err != nil || !reflect.DeepEqual(c, Chain{OSC52{Terminal: &terminal}}) {
+		t.Errorf("Select(osc52) = %v, %v", c, err)
+	}
+	if _, err := Select("pigeon", env(nil), &terminal, "clipboard.txt"); err == nil {
+		t.Error("Select() should fail for unknown strategies")
+	}
+}
+
+func TestOSC52_Write(t *testing.T) {
+	var terminal bytes.Buffer
+	if err := (OSC52{Terminal: &terminal}).Write("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := terminal.String(); got != "\x1b]52;c;aGk=\a" {
+		t.Errorf("sequence = %q", got)
+	}
+
+	terminal.Reset()
+	if err := (OSC52{Terminal: &terminal, Screen: true}).Write("hi"); err != nil {
+		t.Fatal(err)
+	}
+	if got := terminal.String(); got != "\x1bP\x1b]52;c;aGk=\a\x1b\\" {
+		t.Errorf("screen sequence = %q", got)
+	}
+}
+
+// failing is a writer that always fails.
+type failing struct{}
+
+func (failing) Name() string            { return "broken" }
+func (failing) Write(text string) error { return errors.New("no display") }
+
+func TestChain_Write(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "clipboard.txt")
+	name, err := Chain{failing{}, File{Path: filename}}.Write("text")
+	if err != nil || name != filename {
+		t.Fatalf("Write() = %s, %v, want the file to be used", name, err)
+	}
+	if data, _ := os.ReadFile(filename); string(data) != "text" {
+		t.Errorf("file contains %q", data)
+	}
+
+	_, err = Chain{failing{}, File{Path: filepath.Join(filename, "missing")}}.Write("text")
+	if err == nil || !strings.HasPrefix(err.Error(), "broken: no display; ") {
+		t.Errorf("Write() error = %v, want the reasons of all writers", err)
+	}
+}
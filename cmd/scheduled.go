@@ -5,11 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
-	"github.com/atotto/clipboard"
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
@@ -20,8 +21,18 @@ import (
 	"github.com/rwirdemann/nestiles/panel"
 	"github.com/rwirdemann/scheduled"
 	"github.com/rwirdemann/scheduled/board"
+	"github.com/rwirdemann/scheduled/cli"
 	clpboard "github.com/rwirdemann/scheduled/clipboard"
+	"github.com/rwirdemann/scheduled/date"
 	"github.com/rwirdemann/scheduled/file"
+	"github.com/rwirdemann/scheduled/history"
+	"github.com/rwirdemann/scheduled/ical"
+	"github.com/rwirdemann/scheduled/report"
+	"github.com/rwirdemann/scheduled/server"
+	"github.com/rwirdemann/scheduled/sqlite"
+	"github.com/rwirdemann/scheduled/todotxt"
+	"github.com/rwirdemann/scheduled/week"
+	"github.com/sahilm/fuzzy"
 )
 
 var version = "dev"
@@ -33,6 +44,11 @@ const (
 	leftPanel        = 70
 	contextEditPanel = 80
 	statusPanel      = 90
+	detailPanel      = 100
+	searchPanel      = 110
+	palettePanel     = 120
+	archivePanel     = 130
+	copyPanel        = 140
 )
 
 type mode int
@@ -42,6 +58,10 @@ const (
 	modeEdit
 	modeNew
 	modeContexts
+	modeSearch
+	modePalette
+	modeArchive
+	modeCopy
 )
 
 type clearStatusMsg struct{}
@@ -52,6 +72,11 @@ func clearStatusAfter(d time.Duration) tea.Cmd {
 	})
 }
 
+const (
+	autoSaveInterval  = 15 * time.Second
+	saveRetryInterval = 5 * time.Second
+)
+
 type autoSaveMsg struct{}
 
 func autoSaveAfter(d time.Duration) tea.Cmd {
@@ -60,11 +85,24 @@ func autoSaveAfter(d time.Duration) tea.Cmd {
 	})
 }
 
+type externalChangeMsg struct{}
+
+// notifier is implemented by repositories that report changes made by other
+// clients, like server.Client.
+type notifier interface {
+	Changes() <-chan struct{}
+}
+
+// repository stores tasks, contexts and the archive. Saves fail with
+// scheduled.ErrModified if another program saved since the last load, so that
+// the changes can be merged.
 type repository interface {
-	LoadContexts() []scheduled.Context
-	LoadTasks() []scheduled.Task
-	SaveContexts(contexts []scheduled.Context)
-	SaveTasks(contexts []scheduled.Task)
+	LoadContexts() ([]scheduled.Context, error)
+	LoadTasks() ([]scheduled.Task, error)
+	SaveContexts(contexts []scheduled.Context) error
+	SaveTasks(contexts []scheduled.Task) error
+	LoadArchive() ([]scheduled.ArchivedTask, error)
+	SaveArchive(archive []scheduled.ArchivedTask) error
 }
 
 type model struct {
@@ -75,8 +113,14 @@ type model struct {
 
 	form       *huh.Form
 	repository repository
+	history    *history.History
+	clock      date.Clock
+
+	// saved is the state last loaded from or saved to the repository
+	saved history.State
 
 	showHelp        bool
+	showDetails     bool
 	keys            scheduled.KeyMap
 	contextViewKeys scheduled.ContextViewKeyMap
 	help            help.Model
@@ -89,11 +133,57 @@ type model struct {
 	contextEdit      textinput.Model
 	mode             mode
 
+	search        textinput.Model
+	searchResults []board.Match
+	searchIndex   int
+
+	palette         textinput.Model
+	paletteCommands commandList
+	paletteMatches  fuzzy.Matches
+	paletteIndex    int
+
+	// The archive view shows the tasks archived in a week, filtered by
+	// archiveContext, which is allContexts for the tasks of all contexts.
+	archiveYear    int
+	archiveWeek    int
+	archiveContext scheduled.Context
+	archiveTasks   []scheduled.ArchivedTask
+	archiveIndex   int
+
 	statusMessage string
 	statusTimeout time.Time
+
+	// todoFile is the todo.txt file the week is exported to and imported from.
+	todoFile string
+
+	// calendarFile is the iCalendar file the week is exported to.
+	calendarFile string
+
+	// templateDir contains the user-defined report templates.
+	templateDir string
+
+	// clipboard receives copied tasks and reports.
+	clipboard clpboard.Chain
+
+	// copyFormat is the clipboard format tasks are copied in. If it is empty,
+	// the copy panel asks for the format of copyTasks.
+	copyFormat string
+	copyTasks  []scheduled.Task
+	copyIndex  int
+
+	// sequence collects multi key bindings and counts of the vim preset. It
+	// is nil for presets with single key bindings only.
+	sequence *scheduled.Sequence
+
+	// count is the count typed before the key being handled, or 0.
+	count int
+
+	// quitPending is set if saving failed on quit. Quitting again discards
+	// unsaved changes.
+	quitPending bool
 }
 
-func newModel(root panel.Model, repository repository) model {
+func newModel(root panel.Model, repository repository, clock date.Clock) (model, error) {
 	h := help.New()
 	h.Styles.FullKey = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
 	h.Styles.FullDesc = lipgloss.NewStyle().Foreground(lipgloss.Color("252"))
@@ -102,7 +192,10 @@ func newModel(root panel.Model, repository repository) model {
 	contextListDelegate := list.NewDefaultDelegate()
 	contextListDelegate.ShowDescription = false
 	contextListDelegate.SetSpacing(0)
-	contexts := repository.LoadContexts()
+	contexts, err := repository.LoadContexts()
+	if err != nil {
+		return model{}, err
+	}
 	items := make([]list.Item, len(contexts))
 	for i, v := range contexts {
 		items[i] = v
@@ -112,9 +205,15 @@ func newModel(root panel.Model, repository repository) model {
 	contextList.SetShowStatusBar(false)
 	contextList.Title = "Contexts"
 
+	b, err := board.NewModel(repository, clock)
+	if err != nil {
+		return model{}, err
+	}
+
 	m := model{
 		root:            root,
 		repository:      repository,
+		clock:           clock,
 		keys:            scheduled.Keys,
 		contextViewKeys: scheduled.ContextViewKeys,
 		help:            h,
@@ -122,42 +221,207 @@ func newModel(root panel.Model, repository repository) model {
 		mode:            modeNormal,
 		contextList:     contextList,
 		contextEdit:     textinput.New(),
-		board:           board.NewModel(repository),
+		search:          textinput.New(),
+		palette:         textinput.New(),
+		board:           b,
+		history:         history.New(),
+		todoFile:        file.Path("todo.txt"),
+		calendarFile:    file.Path("week.ics"),
+		templateDir:     file.Path("templates"),
+		clipboard:       clpboard.Auto(os.Getenv, os.Stderr, file.Path("clipboard.txt")),
 	}
 	m.contextEdit.Placeholder = "Context"
 	m.contextEdit.Width = 20
+	m.search.Placeholder = "Search tasks"
+	m.palette.Placeholder = "Type a command"
+	m.saved = m.state()
+	return m, nil
+}
+
+// withKeys returns the model using the given key bindings. With sequences,
+// bindings may consist of several keys and be preceded by a count.
+func (m model) withKeys(keys scheduled.KeyMap, contextKeys scheduled.ContextViewKeyMap, sequences bool) model {
+	m.keys = keys
+	m.contextViewKeys = contextKeys
+	m.sequence = nil
+	if sequences {
+		m.sequence = scheduled.NewSequence(keys)
+	}
 	return m
 }
 
 func (m model) Init() tea.Cmd {
-	return autoSaveAfter(15 * time.Second)
+	return tea.Batch(autoSaveAfter(autoSaveInterval), m.watchChanges())
 }
 
-func (m model) Save() {
-	m.board.SaveTasks()
-	m.repository.SaveContexts(m.contexts())
+// watchChanges waits for the next change made by another client if the
+// repository reports such changes.
+func (m model) watchChanges() tea.Cmd {
+	n, ok := m.repository.(notifier)
+	if !ok {
+		return nil
+	}
+	changes := n.Changes()
+	return func() tea.Msg {
+		<-changes
+		return externalChangeMsg{}
+	}
+}
+
+// reload merges the tasks and contexts changed by another program or client
+// into the board.
+func (m model) reload() (model, tea.Cmd) {
+	m, conflicts, err := m.merge()
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Reloading failed: %v", err))
+	}
+	return m.showStatusMessage(mergeMessage(conflicts))
+}
+
+// merge loads the tasks and contexts of the repository and merges them with
+// the changes made on the board since the last load or save. Tasks changed on
+// both sides keep the version of the board, their names are returned. The
+// merge is recorded in the undo history.
+func (m model) merge() (model, []string, error) {
+	tasks, err := m.repository.LoadTasks()
+	if err != nil {
+		return m, nil, err
+	}
+	contexts, err := m.repository.LoadContexts()
+	if err != nil {
+		return m, nil, err
+	}
+
+	archive, err := m.board.LoadArchive()
+	if err != nil {
+		return m, nil, err
+	}
+
+	remote := history.State{Tasks: tasks, Contexts: contexts, Archive: archive}
+	before := m.state()
+	merged, conflicts := history.Merge(m.saved, before, remote)
+	m = m.restore(merged)
+	if !reflect.DeepEqual(before, m.state()) {
+		m.history.Record("reload", before)
+	}
+	m.saved = remote
+	return m, conflicts, nil
+}
+
+// save saves the board. If the repository was changed by another program in
+// the meantime, the changes are merged first.
+func (m model) save() (model, []string, error) {
+	var conflicts []string
+	err := m.Save()
+	if errors.Is(err, scheduled.ErrModified) {
+		if m, conflicts, err = m.merge(); err == nil {
+			err = m.Save()
+		}
+	}
+	if err != nil {
+		return m, conflicts, err
+	}
+	m.saved = m.state()
+	return m, conflicts, nil
+}
+
+func mergeMessage(conflicts []string) string {
+	if len(conflicts) == 0 {
+		return "Reloaded changes made by another program"
+	}
+	return fmt.Sprintf("Merged changes made by another program, kept your version of '%s'", strings.Join(conflicts, "', '"))
+}
+
+func (m model) Save() error {
+	return errors.Join(m.board.SaveTasks(), m.repository.SaveContexts(m.contexts()))
 }
 
 func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	k, ok := msg.(tea.KeyMsg)
+	if !ok || m.sequence == nil || m.mode != modeNormal {
+		return m.update(msg)
+	}
+
+	k, count, complete := m.sequence.Feed(k)
+	if !complete {
+		return m, nil
+	}
+
+	// Moves between days take the count as number of days, other actions are
+	// repeated
+	if key.Matches(k, m.keys.ShiftLeft, m.keys.ShiftRight, m.keys.NextDay, m.keys.PrevDay) {
+		m.count = count
+		updated, cmd := m.update(k)
+		m = updated.(model)
+		m.count = 0
+		return m, cmd
+	}
+	var cmds []tea.Cmd
+	for range count {
+		updated, cmd := m.update(k)
+		m = updated.(model)
+		cmds = append(cmds, cmd)
+		if m.mode != modeNormal {
+			break
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// days returns the number of days to move by, which is the count typed
+// before the key or 1.
+func (m model) days() int {
+	return max(m.count, 1)
+}
+
+func (m model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
+	if msg, ok := msg.(tea.KeyMsg); ok && m.mode == modeSearch {
+		return m.updateSearch(msg)
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok && m.mode == modePalette {
+		return m.updatePalette(msg)
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok && m.mode == modeArchive {
+		return m.updateArchive(msg)
+	}
+	if msg, ok := msg.(tea.KeyMsg); ok && m.mode == modeCopy {
+		return m.updateCopy(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.Quit):
-			m.Save()
+			m, _, err := m.save()
+			if err != nil && !m.quitPending {
+				m.quitPending = true
+				return m.showStatusMessage(fmt.Sprintf("Saving failed: %v. Press %s again to quit without saving", err, m.keys.Quit.Help().Key))
+			}
 			return m, tea.Quit
 		}
 	case clearStatusMsg:
-		if time.Now().After(m.statusTimeout) {
+		if m.clock.Now().After(m.statusTimeout) {
 			m.statusMessage = ""
 			m.root = m.root.Hide(statusPanel)
 		}
 		return m, nil
 	case autoSaveMsg:
-		m.Save()
-		return m, autoSaveAfter(15 * time.Second)
+		m, conflicts, err := m.save()
+		if err != nil {
+			m, cmd = m.showStatusMessage(fmt.Sprintf("Saving failed, retrying in %s: %v", saveRetryInterval, err))
+			return m, tea.Batch(cmd, autoSaveAfter(saveRetryInterval))
+		}
+		m.quitPending = false
+		if len(conflicts) > 0 {
+			m, cmd = m.showStatusMessage(mergeMessage(conflicts))
+		}
+		return m, tea.Batch(cmd, autoSaveAfter(autoSaveInterval))
+	case externalChangeMsg:
+		m, cmd = m.reload()
+		return m, tea.Batch(cmd, m.watchChanges())
 	}
 
 	switch m.mode {
@@ -166,13 +430,19 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if f, ok := form.(*huh.Form); ok {
 			m.form = f
 			if f.State == huh.StateCompleted {
-				title := m.form.GetString("title")
-				context := m.form.GetInt("context")
+				// The rule is one of scheduled.RecurrenceOptions and always valid
+				recurrence, _ := scheduled.ParseRecurrence(m.form.GetString("recurrence"))
+				task := scheduled.Task{
+					Name:       m.form.GetString("title"),
+					Desc:       m.form.GetString("description"),
+					Context:    m.form.GetInt("context"),
+					Recurrence: recurrence,
+				}
 				if m.mode == modeEdit {
-					m.board.UpdateTask(title, context)
+					m.track(m.taskLabel("edit", m.board.LastFocus), func() { m.board.UpdateTask(task) })
 				}
 				if m.mode == modeNew {
-					m.board.CreateTask(title, context)
+					m.track(fmt.Sprintf("create '%s'", task.Name), func() { m.board.CreateTask(task) })
 				}
 				m.root = m.root.Hide(panelEdit)
 				if m.showHelp {
@@ -203,9 +473,11 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				case key.Matches(msg, m.keys.Enter):
 					var err error
+					before := m.state()
 					if m, err = m.addContext(m.contextEdit.Value()); err != nil {
 						return m.showStatusMessage(err.Error())
 					}
+					m.history.Record(fmt.Sprintf("add context '%s'", m.contextEdit.Value()), before)
 					m.contextEdit.SetValue("")
 					m.root = m.root.Hide(contextEditPanel)
 					m.editContextShown = false
@@ -228,7 +500,7 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				i := m.contextList.SelectedItem()
 				m.board.SetContext(i.(scheduled.Context))
 				m.root = m.root.Hide(leftPanel)
-				m.board.SetListTitle(board.Inbox, fmt.Sprintf("[ESC] Inbox (Week %d)", m.board.Week()))
+				m.board.SetListTitle(board.Inbox, m.inboxTitle())
 				m.root = m.root.SetFocus(m.board.LastFocus)
 				return m, nil
 			case key.Matches(msg, m.contextViewKeys.NewContext):
@@ -239,9 +511,13 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, cmd
 			case key.Matches(msg, m.contextViewKeys.DeleteContext):
 				var err error
+				before := m.state()
 				if m, err = m.deleteContext(); err != nil {
 					return m.showStatusMessage(err.Error())
 				}
+				if !reflect.DeepEqual(before, m.state()) {
+					m.history.Record("delete context", before)
+				}
 			}
 		}
 		m.contextList, cmd = m.contextList.Update(msg)
@@ -271,18 +547,46 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		case key.Matches(msg, m.keys.ShiftLeft):
 			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
-				m.board.MoveTask(focusedPanel.ID, focusedPanel.ID-1)
+				n := m.days()
+				to := max(focusedPanel.ID-n, board.Inbox)
+				if err := m.moveTask(focusedPanel.ID, to, func() { m.board.MoveMarked(-n) }); err != nil {
+					return m.showStatusMessage(err.Error())
+				}
 			}
 		case key.Matches(msg, m.keys.ShiftRight):
 			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
-				m.board.MoveTask(focusedPanel.ID, focusedPanel.ID+1)
+				n := m.days()
+				to := min(focusedPanel.ID+n, board.Sunday)
+				if err := m.moveTask(focusedPanel.ID, to, func() { m.board.MoveMarked(n) }); err != nil {
+					return m.showStatusMessage(err.Error())
+				}
 			}
+		case key.Matches(msg, m.keys.NextDay, m.keys.PrevDay):
+			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID >= board.Inbox && focusedPanel.ID <= board.Sunday {
+				n := m.days()
+				if key.Matches(msg, m.keys.PrevDay) {
+					n = -n
+				}
+				lists := board.Sunday + 1
+				next := ((focusedPanel.ID+n)%lists + lists) % lists
+				m.root = m.root.SetFocus(next)
+				m.board.DeselectAndRestoreIndex(next)
+				return m, nil
+			}
+		case key.Matches(msg, m.keys.Top):
+			focusedPanel, _ := m.root.Focused()
+			m.board.SelectFirst(focusedPanel.ID)
+			return m, nil
+		case key.Matches(msg, m.keys.Bottom):
+			focusedPanel, _ := m.root.Focused()
+			m.board.SelectLast(focusedPanel.ID)
+			return m, nil
 		case key.Matches(msg, m.keys.ShiftUp):
 			focusedPanel, _ := m.root.Focused()
-			m.board.MoveUp(focusedPanel.ID)
+			m.track(m.taskLabel("move up", focusedPanel.ID), func() { m.board.MoveUp(focusedPanel.ID) })
 		case key.Matches(msg, m.keys.ShiftDown):
 			focusedPanel, _ := m.root.Focused()
-			m.board.MoveDown(focusedPanel.ID)
+			m.track(m.taskLabel("move down", focusedPanel.ID), func() { m.board.MoveDown(focusedPanel.ID) })
 		case key.Matches(msg, m.keys.New):
 			// Preselect the currently selected context
 			selectedContext := m.board.GetSelectedContext()
@@ -294,17 +598,21 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.mode = modeNew
 			return m, m.form.Init()
 		case key.Matches(msg, m.keys.Esc):
+			if m.board.HasMarks() {
+				m.board.ClearMarks()
+				return m, nil
+			}
 			m.root = m.root.Hide(panelEdit)
 			m.root = m.root.SetFocus(board.Inbox)
 			m.board.DeselectAndRestoreIndex(board.Inbox)
 			return m, nil
 		case key.Matches(msg, m.keys.Space):
 			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
-				m.board.ToggleDone(focusedPanel.ID)
+				m.apply("check / uncheck", focusedPanel.ID, func() { m.board.ToggleDone(focusedPanel.ID) }, m.board.ToggleMarkedDone)
 			}
 		case key.Matches(msg, m.keys.Back):
 			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
-				m.board.DeleteTask(focusedPanel.ID)
+				m.apply("delete", focusedPanel.ID, func() { m.board.DeleteTask(focusedPanel.ID) }, m.board.DeleteMarked)
 			}
 		case key.Matches(msg, m.keys.Enter):
 			focusedPanel, _ := m.root.Focused()
@@ -323,28 +631,93 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.board.DeselectAndRestoreIndex(panelNum)
 			return m, nil
 		case key.Matches(msg, m.keys.MoveToToday):
-			today := time.Now().Weekday()
 			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
-				m.board.MoveTask(focusedPanel.ID, int(today))
+				today := m.board.Today()
+				if err := m.moveTask(focusedPanel.ID, today, func() { m.board.MoveMarkedTo(today) }); err != nil {
+					return m.showStatusMessage(err.Error())
+				}
 			}
 		case key.Matches(msg, m.keys.MoveToInbox):
 			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
-				m.board.MoveTask(focusedPanel.ID, board.Inbox)
+				if err := m.moveTask(focusedPanel.ID, board.Inbox, func() { m.board.MoveMarkedTo(board.Inbox) }); err != nil {
+					return m.showStatusMessage(err.Error())
+				}
 			}
-		case key.Matches(msg, m.keys.Contexts):
-			m.mode = modeContexts
-			m.root = m.root.Show(leftPanel)
-			m.root.SetFocus(leftPanel)
+		case key.Matches(msg, m.keys.Pin):
+			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
+				var err error
+				m.track(m.taskLabel("pin / unpin", focusedPanel.ID), func() { err = m.board.TogglePin(focusedPanel.ID) })
+				if err != nil {
+					return m.showStatusMessage(err.Error())
+				}
+			}
+		case key.Matches(msg, m.keys.Undo):
+			s, label, ok := m.history.Undo(m.state())
+			if !ok {
+				return m.showStatusMessage("Nothing to undo")
+			}
+			m = m.restore(s)
+			return m.showStatusMessage(fmt.Sprintf("Undid %s", label))
+		case key.Matches(msg, m.keys.Redo):
+			s, label, ok := m.history.Redo(m.state())
+			if !ok {
+				return m.showStatusMessage("Nothing to redo")
+			}
+			m = m.restore(s)
+			return m.showStatusMessage(fmt.Sprintf("Redid %s", label))
+		case key.Matches(msg, m.keys.Details):
+			m.showDetails = !m.showDetails
+			if m.showDetails {
+				m.root = m.root.Show(detailPanel)
+			} else {
+				m.root = m.root.Hide(detailPanel)
+			}
+			return m, nil
+		case key.Matches(msg, m.keys.Descriptions):
+			m.board.SetShowDescription(!m.board.ShowDescription())
 			return m, nil
+		case key.Matches(msg, m.keys.Search):
+			m.mode = modeSearch
+			m.search.SetValue("")
+			m.searchResults = nil
+			m.searchIndex = 0
+			m.root = m.root.Hide(panelHelp)
+			m.root = m.root.Show(searchPanel)
+			m.root = m.root.SetFocus(searchPanel)
+			return m, m.search.Focus()
+		case key.Matches(msg, m.keys.Palette):
+			m.mode = modePalette
+			m.palette.SetValue("")
+			m.paletteCommands = m.commands()
+			m.paletteMatches = m.paletteCommands.find("")
+			m.paletteIndex = 0
+			m.root = m.root.Hide(panelHelp)
+			m.root = m.root.Show(palettePanel)
+			m.root = m.root.SetFocus(palettePanel)
+			return m, m.palette.Focus()
+		case key.Matches(msg, m.keys.Mark):
+			if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != panelEdit {
+				m.board.ToggleMark(focusedPanel.ID)
+				return m.showStatusMessage(fmt.Sprintf("%d tasks marked", len(m.board.MarkedTasks())))
+			}
+		case key.Matches(msg, m.keys.Archive):
+			return m.openArchive(), nil
+		case key.Matches(msg, m.keys.Contexts):
+			return m.openContexts(), nil
+		case key.Matches(msg, m.keys.Report):
+			return m.copyReport()
 		case key.Matches(msg, m.keys.CopyTasks):
 			focusedPanel, _ := m.root.Focused()
 			if focusedPanel.ID != panelEdit {
-				tasks := m.board.GetTasksForPanel(focusedPanel.ID)
-				clipboardText := clpboard.FormatTasks(m.contexts(), tasks)
-				_ = clipboard.WriteAll(clipboardText)
-				return m.showStatusMessage(fmt.Sprintf("%d tasks copied to clipboard", len(tasks)))
+				tasks := m.dayTasks(focusedPanel.ID)
+				if m.board.HasMarks() {
+					tasks = m.board.MarkedTasks()
+				}
+				return m.copy(tasks)
 			}
 			return m, nil
+		case key.Matches(msg, m.keys.CopyWeek):
+			return m.copy(m.weekTasks())
 		}
 	}
 	m.root, cmd = m.root.Update(msg)
@@ -360,6 +733,532 @@ func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// updateSearch handles keys while the search panel is shown. Enter jumps to
+// the selected result.
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Esc):
+		return m.closeSearch(), nil
+	case key.Matches(msg, m.keys.Enter):
+		if m.searchIndex < len(m.searchResults) {
+			m = m.closeSearch()
+			return m.jumpTo(m.searchResults[m.searchIndex]), nil
+		}
+		return m.closeSearch(), nil
+	}
+	if index, ok := moveSelection(msg, m.searchIndex, len(m.searchResults)); ok {
+		m.searchIndex = index
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	query := m.search.Value()
+	m.search, cmd = m.search.Update(msg)
+	if m.search.Value() != query {
+		m.searchResults = m.board.Search(m.search.Value())
+		m.searchIndex = 0
+	}
+	return m, cmd
+}
+
+// closeSearch hides the search panel and returns to the board.
+func (m model) closeSearch() model {
+	m.mode = modeNormal
+	m.search.Blur()
+	m.root = m.root.Hide(searchPanel)
+	if m.showHelp {
+		m.root = m.root.Show(panelHelp)
+	}
+	m.root = m.root.SetFocus(m.board.LastFocus)
+	return m
+}
+
+// moveSelection moves the selected index of a result list of length n with
+// the arrow keys. It returns false for other keys.
+func moveSelection(msg tea.KeyMsg, index, n int) (int, bool) {
+	switch msg.Type {
+	case tea.KeyUp, tea.KeyCtrlP:
+		return max(index-1, 0), true
+	case tea.KeyDown, tea.KeyCtrlN:
+		return max(min(index+1, n-1), 0), true
+	}
+	return index, false
+}
+
+// command is an entry of the command palette.
+type command struct {
+	name string
+	key  string
+	run  func(m model) (tea.Model, tea.Cmd)
+}
+
+// commandList implements fuzzy.Source.
+type commandList []command
+
+func (c commandList) String(i int) string {
+	return c[i].name
+}
+
+func (c commandList) Len() int {
+	return len(c)
+}
+
+// find returns the commands matching the query best first, or all commands
+// for an empty query.
+func (c commandList) find(query string) fuzzy.Matches {
+	if strings.TrimSpace(query) == "" {
+		matches := make(fuzzy.Matches, len(c))
+		for i := range c {
+			matches[i] = fuzzy.Match{Str: c[i].name, Index: i}
+		}
+		return matches
+	}
+	return fuzzy.FindFrom(query, c)
+}
+
+// keyMsg returns a key message matching the binding.
+func keyMsg(b key.Binding) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(b.Keys()[0])}
+}
+
+// commands returns the entries of the command palette: every action of the
+// key maps and commands to move the selected task, focus a day, switch the
+// context, copy the week in a format or go to a week.
+func (m model) commands() commandList {
+	var commands commandList
+	for _, column := range m.keys.FullHelp() {
+		for _, b := range column {
+			if !b.Enabled() || b.Help() == m.keys.Num.Help() || b.Help() == m.keys.Palette.Help() {
+				continue
+			}
+			commands = append(commands, command{name: b.Help().Desc, key: b.Help().Key, run: func(m model) (tea.Model, tea.Cmd) {
+				return m.update(keyMsg(b))
+			}})
+		}
+	}
+	for _, column := range m.contextViewKeys.FullHelp() {
+		for _, b := range column {
+			if !b.Enabled() || b.Help() == m.contextViewKeys.CloseView.Help() {
+				continue
+			}
+			commands = append(commands, command{name: "contexts: " + b.Help().Desc, key: b.Help().Key, run: func(m model) (tea.Model, tea.Cmd) {
+				return m.openContexts().update(keyMsg(b))
+			}})
+		}
+	}
+	for day := board.Inbox; day <= board.Sunday; day++ {
+		commands = append(commands, command{name: "move task to " + board.DayName(day), run: func(m model) (tea.Model, tea.Cmd) {
+			from := m.board.LastFocus
+			if err := m.moveTask(from, day, func() { m.board.MoveMarkedTo(day) }); err != nil {
+				return m.showStatusMessage(err.Error())
+			}
+			return m, nil
+		}})
+	}
+	for _, c := range m.contexts() {
+		commands = append(commands, command{name: "set context " + c.Name, run: func(m model) (tea.Model, tea.Cmd) {
+			from := m.board.LastFocus
+			m.apply("set context of", from, func() {
+				if t, exists := m.board.GetSelectedTask(from); exists {
+					t.Context = c.ID
+					m.board.UpdateTask(t)
+				}
+			}, func() { m.board.SetMarkedContext(c.ID) })
+			return m, nil
+		}})
+	}
+	for day := board.Inbox; day <= board.Sunday; day++ {
+		commands = append(commands, command{name: "focus " + board.DayName(day), run: func(m model) (tea.Model, tea.Cmd) {
+			m.root = m.root.SetFocus(day)
+			m.board.DeselectAndRestoreIndex(day)
+			return m, nil
+		}})
+	}
+	for _, c := range m.contexts() {
+		commands = append(commands, command{name: "switch context " + c.Name, run: func(m model) (tea.Model, tea.Cmd) {
+			m.board.SetContext(c)
+			m.board.SetListTitle(board.Inbox, m.inboxTitle())
+			return m, nil
+		}})
+	}
+	commands = append(commands,
+		command{name: "export todo.txt", run: func(m model) (tea.Model, tea.Cmd) { return m.exportTodoTxt() }},
+		command{name: "import todo.txt", run: func(m model) (tea.Model, tea.Cmd) { return m.importTodoTxt() }},
+		command{name: "export calendar", run: func(m model) (tea.Model, tea.Cmd) { return m.exportCalendar() }},
+	)
+	for _, format := range clpboard.Formats() {
+		commands = append(commands, command{name: "copy week as " + format, run: func(m model) (tea.Model, tea.Cmd) {
+			return m.copyAs(format, m.weekTasks())
+		}})
+	}
+	year := m.board.Year()
+	for week := 1; week <= date.WeeksInYear(year); week++ {
+		commands = append(commands, command{name: fmt.Sprintf("go to week %d", week), run: func(m model) (tea.Model, tea.Cmd) {
+			m.board.GoToWeek(year, week)
+			return m, nil
+		}})
+	}
+	return commands
+}
+
+// updatePalette handles keys while the command palette is shown. Enter runs
+// the selected command.
+func (m model) updatePalette(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Esc):
+		return m.closePalette(), nil
+	case key.Matches(msg, m.keys.Enter):
+		m = m.closePalette()
+		if m.paletteIndex < len(m.paletteMatches) {
+			return m.paletteCommands[m.paletteMatches[m.paletteIndex].Index].run(m)
+		}
+		return m, nil
+	}
+	if index, ok := moveSelection(msg, m.paletteIndex, len(m.paletteMatches)); ok {
+		m.paletteIndex = index
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	query := m.palette.Value()
+	m.palette, cmd = m.palette.Update(msg)
+	if m.palette.Value() != query {
+		m.paletteMatches = m.paletteCommands.find(m.palette.Value())
+		m.paletteIndex = 0
+	}
+	return m, cmd
+}
+
+// closePalette hides the command palette and returns to the board.
+func (m model) closePalette() model {
+	m.mode = modeNormal
+	m.palette.Blur()
+	m.root = m.root.Hide(palettePanel)
+	if m.showHelp {
+		m.root = m.root.Show(panelHelp)
+	}
+	m.root = m.root.SetFocus(m.board.LastFocus)
+	return m
+}
+
+// openArchive shows the tasks archived in the week of the board, filtered by
+// the selected context.
+func (m model) openArchive() model {
+	m.mode = modeArchive
+	m.archiveYear, m.archiveWeek = m.board.Year(), m.board.Week()
+	m.archiveContext = m.board.GetSelectedContext()
+	if m.archiveContext == scheduled.ContextNone {
+		m.archiveContext = allContexts
+	}
+	m = m.refreshArchive()
+	m.root = m.root.Hide(panelHelp)
+	m.root = m.root.Show(archivePanel)
+	m.root = m.root.SetFocus(archivePanel)
+	return m
+}
+
+// allContexts is the filter of the archive view that shows all tasks.
+var allContexts = scheduled.Context{ID: board.AllContexts, Name: "all contexts"}
+
+// updateArchive handles keys while the archive is shown. The week keys browse
+// the weeks, the day keys the contexts, and enter restores the selected task
+// to the Inbox.
+func (m model) updateArchive(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Esc):
+		return m.closeArchive(), nil
+	case key.Matches(msg, m.keys.Left):
+		if m.archiveWeek--; m.archiveWeek < 1 {
+			m.archiveYear--
+			m.archiveWeek = date.WeeksInYear(m.archiveYear)
+		}
+		return m.refreshArchive(), nil
+	case key.Matches(msg, m.keys.Right):
+		if m.archiveWeek++; m.archiveWeek > date.WeeksInYear(m.archiveYear) {
+			m.archiveYear++
+			m.archiveWeek = 1
+		}
+		return m.refreshArchive(), nil
+	case key.Matches(msg, m.keys.NextDay, m.keys.PrevDay):
+		contexts := append([]scheduled.Context{allContexts}, m.contexts()...)
+		i := slices.Index(contexts, m.archiveContext)
+		if key.Matches(msg, m.keys.PrevDay) {
+			i += len(contexts) - 2
+		}
+		m.archiveContext = contexts[(i+1)%len(contexts)]
+		return m.refreshArchive(), nil
+	case key.Matches(msg, m.keys.Enter):
+		if m.archiveIndex >= len(m.archiveTasks) {
+			return m, nil
+		}
+		t := m.archiveTasks[m.archiveIndex].Task
+		var err error
+		m.track(fmt.Sprintf("restore '%s'", t.Name), func() { err = m.board.RestoreArchived(t.ID) })
+		if err != nil {
+			return m.showStatusMessage(err.Error())
+		}
+		m = m.refreshArchive()
+		return m.showStatusMessage(fmt.Sprintf("Restored '%s' to the Inbox", t.Name))
+	}
+	if index, ok := moveSelection(msg, m.archiveIndex, len(m.archiveTasks)); ok {
+		m.archiveIndex = index
+	}
+	return m, nil
+}
+
+// refreshArchive shows the archived tasks of the selected week and context.
+func (m model) refreshArchive() model {
+	m.archiveTasks = m.board.ArchivedTasks(m.archiveYear, m.archiveWeek, m.archiveContext.ID)
+	m.archiveIndex = max(min(m.archiveIndex, len(m.archiveTasks)-1), 0)
+	return m
+}
+
+// closeArchive hides the archive and returns to the board.
+func (m model) closeArchive() model {
+	m.mode = modeNormal
+	m.root = m.root.Hide(archivePanel)
+	if m.showHelp {
+		m.root = m.root.Show(panelHelp)
+	}
+	m.root = m.root.SetFocus(m.board.LastFocus)
+	return m
+}
+
+// weekTasks returns the tasks shown on the board from the Inbox to Sunday.
+// Tasks shown on several days are returned for each day.
+func (m model) weekTasks() []scheduled.Task {
+	var tasks []scheduled.Task
+	for day := board.Inbox; day <= board.Sunday; day++ {
+		tasks = append(tasks, m.dayTasks(day)...)
+	}
+	return tasks
+}
+
+// dayTasks returns the tasks shown on the list of the day, all with that day
+// even if they recur on several days.
+func (m model) dayTasks(day int) []scheduled.Task {
+	tasks := m.board.GetTasksForPanel(day)
+	for i := range tasks {
+		tasks[i].Day = day
+	}
+	return tasks
+}
+
+// copy copies the tasks to the clipboard in the configured format, or shows
+// the copy panel to choose the format.
+func (m model) copy(tasks []scheduled.Task) (model, tea.Cmd) {
+	if m.copyFormat != "" {
+		return m.copyAs(m.copyFormat, tasks)
+	}
+	m.mode = modeCopy
+	m.copyTasks = tasks
+	m.copyIndex = 0
+	m.root = m.root.Hide(panelHelp)
+	m.root = m.root.Show(copyPanel)
+	m.root = m.root.SetFocus(copyPanel)
+	return m, nil
+}
+
+// copyAs copies the tasks to the clipboard in the format of the given name.
+func (m model) copyAs(format string, tasks []scheduled.Task) (model, tea.Cmd) {
+	text, err := clpboard.Format(format, m.contexts(), tasks)
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Copy failed: %v", err))
+	}
+	used, err := m.clipboard.Write(text)
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Copy failed: %v", err))
+	}
+	return m.showStatusMessage(fmt.Sprintf("%d tasks copied to %s as %s", len(tasks), used, format))
+}
+
+// updateCopy handles keys while the copy panel is shown. Enter copies the
+// tasks in the selected format.
+func (m model) updateCopy(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch {
+	case key.Matches(msg, m.keys.Esc):
+		return m.closeCopy(), nil
+	case key.Matches(msg, m.keys.Enter):
+		m = m.closeCopy()
+		return m.copyAs(clpboard.Formats()[m.copyIndex], m.copyTasks)
+	}
+	if index, ok := moveSelection(msg, m.copyIndex, len(clpboard.Formats())); ok {
+		m.copyIndex = index
+	}
+	return m, nil
+}
+
+// closeCopy hides the copy panel and returns to the board.
+func (m model) closeCopy() model {
+	m.mode = modeNormal
+	m.root = m.root.Hide(copyPanel)
+	if m.showHelp {
+		m.root = m.root.Show(panelHelp)
+	}
+	m.root = m.root.SetFocus(m.board.LastFocus)
+	return m
+}
+
+// viewedWeek returns the tasks of the board in the week shown.
+func (m model) viewedWeek() *week.Week {
+	return week.New(m.board.Tasks(), date.GetMondayOfWeek(m.board.Year(), m.board.Week()))
+}
+
+// exportTodoTxt writes the tasks of the week shown to the todo.txt file.
+func (m model) exportTodoTxt() (model, tea.Cmd) {
+	text := todotxt.Format(m.viewedWeek(), m.contexts())
+	if err := os.WriteFile(m.todoFile, []byte(text), 0644); err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Export failed: %v", err))
+	}
+	return m.showStatusMessage(fmt.Sprintf("Exported %d tasks to %s", strings.Count(text, "\n"), m.todoFile))
+}
+
+// exportCalendar writes the tasks of the week shown to the iCalendar file.
+func (m model) exportCalendar() (model, tea.Cmd) {
+	w := m.viewedWeek()
+	text := ical.Format(w, m.contexts(), m.clock.Now())
+	if err := os.WriteFile(m.calendarFile, []byte(text), 0644); err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Export failed: %v", err))
+	}
+	return m.showStatusMessage(fmt.Sprintf("Exported %d tasks to %s", len(w.View()), m.calendarFile))
+}
+
+// copyReport copies the report of the week shown to the clipboard, rendered
+// with the default template.
+func (m model) copyReport() (model, tea.Cmd) {
+	tmpl, err := report.Load(m.templateDir, report.DefaultTemplate)
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Report failed: %v", err))
+	}
+	r := report.New(m.board.Tasks(), m.contexts(), m.board.Year(), m.board.Week(), 1)
+	var b strings.Builder
+	if err := report.Render(&b, r, tmpl); err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Report failed: %v", err))
+	}
+	used, err := m.clipboard.Write(b.String())
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Copy failed: %v", err))
+	}
+	return m.showStatusMessage(fmt.Sprintf("Report of week %d copied to %s", m.board.Week(), used))
+}
+
+// importTodoTxt adds the tasks of the todo.txt file to the week shown, or
+// updates them if they exist.
+func (m model) importTodoTxt() (model, tea.Cmd) {
+	data, err := os.ReadFile(m.todoFile)
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Import failed: %v", err))
+	}
+	w := m.viewedWeek()
+	tasks, contexts, err := todotxt.Parse(string(data), w, m.contexts())
+	if err != nil {
+		return m.showStatusMessage(fmt.Sprintf("Import failed: %v", err))
+	}
+	updated := todotxt.Import(w, tasks)
+	m = m.trackModel(fmt.Sprintf("import %d tasks", len(tasks)), func(m model) model {
+		return m.restore(history.State{Tasks: w.Tasks, Contexts: contexts, Archive: m.board.Archive()})
+	})
+	return m.showStatusMessage(fmt.Sprintf("Imported %d tasks from %s, %d new and %d updated", len(tasks), m.todoFile, len(tasks)-updated, updated))
+}
+
+// openContexts shows the context view.
+func (m model) openContexts() model {
+	m.mode = modeContexts
+	m.root = m.root.Show(leftPanel)
+	m.root.SetFocus(leftPanel)
+	return m
+}
+
+// inboxTitle returns the title of the Inbox showing the current week.
+func (m model) inboxTitle() string {
+	return fmt.Sprintf("[ESC] Inbox (Week %d, %d)", m.board.Week(), m.board.Year())
+}
+
+// jumpTo focuses the list of the search result and selects its task.
+func (m model) jumpTo(match board.Match) model {
+	m.root = m.root.SetFocus(match.List)
+	m.board.DeselectAndRestoreIndex(match.List)
+	context := m.board.GetSelectedContext()
+	m.board.Reveal(match)
+	if m.board.GetSelectedContext() != context {
+		m.board.SetListTitle(board.Inbox, m.inboxTitle())
+	}
+	return m
+}
+
+// state returns the current tasks, contexts and archive for the undo history.
+func (m model) state() history.State {
+	return history.State{Tasks: m.board.Tasks(), Contexts: m.contexts(), Archive: m.board.Archive()}
+}
+
+// restore replaces all tasks and contexts with the given state.
+func (m model) restore(s history.State) model {
+	m.board.Restore(s.Tasks)
+	m.board.SetArchive(s.Archive)
+	items := make([]list.Item, len(s.Contexts))
+	for i, c := range s.Contexts {
+		items[i] = c
+	}
+	m.contextList.SetItems(items)
+
+	// The selected context may have been removed
+	if !slices.Contains(s.Contexts, m.board.GetSelectedContext()) {
+		m.board.SetContext(scheduled.ContextNone)
+		m.board.SetListTitle(board.Inbox, m.inboxTitle())
+	}
+	return m
+}
+
+// track runs the given board mutation and records the previous state in the
+// undo history if the mutation changed anything.
+func (m model) track(label string, mutate func()) {
+	before := m.state()
+	mutate()
+	if !reflect.DeepEqual(before, m.state()) {
+		m.history.Record(label, before)
+	}
+}
+
+// trackModel is track for mutations that also change the model itself, like
+// its contexts, and returns the changed model.
+func (m model) trackModel(label string, mutate func(model) model) model {
+	before := m.state()
+	m = mutate(m)
+	if !reflect.DeepEqual(before, m.state()) {
+		m.history.Record(label, before)
+	}
+	return m
+}
+
+// apply runs marked on the marked tasks if there are any, or selected on the
+// selected task of the list with the given index otherwise, and records the
+// change in the undo history.
+func (m model) apply(action string, listIndex int, selected, marked func()) {
+	if n := len(m.board.MarkedTasks()); n > 0 {
+		m.track(fmt.Sprintf("%s %d marked tasks", action, n), marked)
+		return
+	}
+	m.track(m.taskLabel(action, listIndex), selected)
+}
+
+// moveTask moves the selected task in the list with the given index to the
+// list to, or runs marked on the marked tasks if there are any. It fails for
+// tasks recurring on several days.
+func (m model) moveTask(from, to int, marked func()) error {
+	var err error
+	m.apply("move", from, func() { err = m.board.MoveTask(from, to) }, marked)
+	return err
+}
+
+// taskLabel describes an action on the selected task in the list with the
+// given index for the undo history.
+func (m model) taskLabel(action string, listIndex int) string {
+	if t, exists := m.board.GetSelectedTask(listIndex); exists {
+		return fmt.Sprintf("%s '%s'", action, t.Name)
+	}
+	return action
+}
+
 func (m model) deleteContext() (model, error) {
 	items := m.contextList.Items()
 	if len(items) == 0 {
@@ -383,7 +1282,7 @@ func (m model) deleteContext() (model, error) {
 
 func (m model) showStatusMessage(s string) (model, tea.Cmd) {
 	m.statusMessage = s
-	m.statusTimeout = time.Now().Add(2 * time.Second)
+	m.statusTimeout = m.clock.Now().Add(2 * time.Second)
 	m.root = m.root.Show(statusPanel)
 	return m, clearStatusAfter(2 * time.Second)
 }
@@ -433,6 +1332,113 @@ func renderHelp(m tea.Model, panelID int, w, h int) string {
 	return model.help.FullHelpView(model.keys.FullHelp())
 }
 
+func renderSearch(m tea.Model, panelID int, w, h int) string {
+	model := m.(model)
+	lines := []string{model.search.View()}
+	if model.search.Value() != "" && len(model.searchResults) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("No tasks found"))
+	}
+
+	contexts := make(map[int]string)
+	for _, c := range model.contexts() {
+		contexts[c.ID] = c.Name
+	}
+	visible := max(h-3, 1)
+	start := max(model.searchIndex-visible+1, 0)
+	selected := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	info := lipgloss.NewStyle().Faint(true)
+	for i := start; i < len(model.searchResults) && i < start+visible; i++ {
+		r := model.searchResults[i]
+		name := "  " + r.Task.Name
+		if i == model.searchIndex {
+			name = selected.Render("> " + r.Task.Name)
+		}
+		lines = append(lines, name+" "+info.Render(fmt.Sprintf("%s · %s", r.Day(), contexts[r.Task.Context])))
+	}
+	return lipgloss.NewStyle().Width(w - 2).MaxHeight(h - 2).Render(strings.Join(lines, "\n"))
+}
+
+func renderArchive(m tea.Model, panelID int, w, h int) string {
+	model := m.(model)
+	title := lipgloss.NewStyle().Bold(true)
+	info := lipgloss.NewStyle().Faint(true)
+	selected := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	lines := []string{title.Render(fmt.Sprintf("Archive (Week %d, %d) - %s", model.archiveWeek, model.archiveYear, model.archiveContext.Name))}
+	if len(model.archiveTasks) == 0 {
+		lines = append(lines, info.Render("No tasks archived"))
+	}
+
+	contexts := make(map[int]string)
+	for _, c := range model.contexts() {
+		contexts[c.ID] = c.Name
+	}
+	visible := max(h-4, 1)
+	start := max(model.archiveIndex-visible+1, 0)
+	for i := start; i < len(model.archiveTasks) && i < start+visible; i++ {
+		a := model.archiveTasks[i]
+		name := "  ✓ " + a.Task.Name
+		if i == model.archiveIndex {
+			name = selected.Render("> ✓ " + a.Task.Name)
+		}
+		lines = append(lines, name+" "+info.Render(fmt.Sprintf("%s · %s", a.Completed.Format("Monday 02.01. 15:04"), contexts[a.Task.Context])))
+	}
+
+	k := model.keys
+	lines = append(lines, info.Render(fmt.Sprintf("%s/%s week · %s/%s context · %s restore · %s close",
+		k.Left.Help().Key, k.Right.Help().Key, k.PrevDay.Help().Key, k.NextDay.Help().Key, k.Enter.Help().Key, k.Esc.Help().Key)))
+	return lipgloss.NewStyle().Width(w - 2).MaxHeight(h - 2).Render(strings.Join(lines, "\n"))
+}
+
+func renderCopy(m tea.Model, panelID int, w, h int) string {
+	model := m.(model)
+	info := lipgloss.NewStyle().Faint(true)
+	selected := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	lines := []string{lipgloss.NewStyle().Bold(true).Render(fmt.Sprintf("Copy %d tasks as", len(model.copyTasks)))}
+	for i, format := range clpboard.Formats() {
+		if i == model.copyIndex {
+			lines = append(lines, selected.Render("> "+format))
+		} else {
+			lines = append(lines, "  "+format)
+		}
+	}
+	lines = append(lines, info.Render(fmt.Sprintf("%s copy · %s cancel", model.keys.Enter.Help().Key, model.keys.Esc.Help().Key)))
+	return lipgloss.NewStyle().Width(w - 2).MaxHeight(h - 2).Render(strings.Join(lines, "\n"))
+}
+
+func renderPalette(m tea.Model, panelID int, w, h int) string {
+	model := m.(model)
+	lines := []string{model.palette.View()}
+	if len(model.paletteMatches) == 0 {
+		lines = append(lines, lipgloss.NewStyle().Faint(true).Render("No matching command"))
+	}
+
+	visible := max(h-3, 1)
+	start := max(model.paletteIndex-visible+1, 0)
+	matched := lipgloss.NewStyle().Underline(true)
+	selected := lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("205"))
+	info := lipgloss.NewStyle().Faint(true)
+	for i := start; i < len(model.paletteMatches) && i < start+visible; i++ {
+		match := model.paletteMatches[i]
+		var name strings.Builder
+		for j, r := range match.Str {
+			if slices.Contains(match.MatchedIndexes, j) {
+				name.WriteString(matched.Render(string(r)))
+			} else {
+				name.WriteRune(r)
+			}
+		}
+		line := "  " + name.String()
+		if i == model.paletteIndex {
+			line = selected.Render("> ") + selected.Render(name.String())
+		}
+		if k := model.paletteCommands[match.Index].key; k != "" {
+			line += " " + info.Render(k)
+		}
+		lines = append(lines, line)
+	}
+	return lipgloss.NewStyle().Width(w - 2).MaxHeight(h - 2).Render(strings.Join(lines, "\n"))
+}
+
 func renderContextPanel(m tea.Model, panelID int, w, h int) string {
 	model := m.(model)
 	model.contextList.SetSize(w, h-2)
@@ -440,6 +1446,20 @@ func renderContextPanel(m tea.Model, panelID int, w, h int) string {
 	return model.contextList.View() + "\n" + help
 }
 
+func renderDetails(m tea.Model, panelID int, w, h int) string {
+	model := m.(model)
+	t, exists := model.board.GetSelectedTask(model.board.LastFocus)
+	if !exists {
+		return lipgloss.NewStyle().Faint(true).Render("No task selected")
+	}
+	title := lipgloss.NewStyle().Bold(true).Render(t.Name)
+	desc := t.Desc
+	if desc == "" {
+		desc = lipgloss.NewStyle().Faint(true).Render("No description")
+	}
+	return lipgloss.NewStyle().Width(w - 2).MaxHeight(h - 2).Render(title + "\n" + desc)
+}
+
 func renderContextEditPanel(m tea.Model, panelID int, w, h int) string {
 	model := m.(model)
 	return model.contextEdit.View()
@@ -463,7 +1483,7 @@ func (m model) contexts() []scheduled.Context {
 	return contexts
 }
 
-func createModel(repository repository) model {
+func createModel(repository repository, clock date.Clock) (model, error) {
 	row1 := panel.New().WithId(20).WithRatio(41).WithLayout(panel.LayoutDirectionHorizontal)
 	for i := range 4 {
 		p := panel.New().WithId(i).WithRatio(25).WithBorder().WithContent(renderPanel)
@@ -479,14 +1499,24 @@ func createModel(repository repository) model {
 		row2 = row2.Append(p)
 	}
 	statusPanel := panel.New().WithId(statusPanel).WithRatio(18).WithContent(renderStatus).WithBorder().WithVisible(false).WithMaxHeight(3)
-	editPanel := panel.New().WithId(panelEdit).WithRatio(18).WithContent(renderPanel).WithBorder().WithVisible(false).WithMaxHeight(6)
+	detailPanel := panel.New().WithId(detailPanel).WithRatio(18).WithContent(renderDetails).WithBorder().WithVisible(false).WithMaxHeight(8)
+	editPanel := panel.New().WithId(panelEdit).WithRatio(18).WithContent(renderPanel).WithBorder().WithVisible(false).WithMaxHeight(7)
+	searchPanel := panel.New().WithId(searchPanel).WithRatio(18).WithContent(renderSearch).WithBorder().WithVisible(false).WithMaxHeight(10)
+	palettePanel := panel.New().WithId(palettePanel).WithRatio(18).WithContent(renderPalette).WithBorder().WithVisible(false).WithMaxHeight(10)
+	archivePanel := panel.New().WithId(archivePanel).WithRatio(18).WithContent(renderArchive).WithBorder().WithVisible(false).WithMaxHeight(12)
+	copyPanel := panel.New().WithId(copyPanel).WithRatio(18).WithContent(renderCopy).WithBorder().WithVisible(false).WithMaxHeight(12)
 	helpPanel := panel.New().WithId(panelHelp).WithRatio(18).WithContent(renderHelp).WithBorder().WithVisible(true).WithMaxHeight(6)
 
 	rightPanel := panel.New().WithRatio(84).WithLayout(panel.LayoutDirectionVertical).
 		Append(statusPanel).
 		Append(row1).
 		Append(row2).
+		Append(detailPanel).
 		Append(editPanel).
+		Append(searchPanel).
+		Append(palettePanel).
+		Append(archivePanel).
+		Append(copyPanel).
 		Append(helpPanel)
 
 	leftPanel := panel.New().WithId(leftPanel).WithRatio(16).WithVisible(false).WithLayout(panel.LayoutDirectionVertical)
@@ -500,25 +1530,106 @@ func createModel(repository repository) model {
 		Append(leftPanel).
 		Append(rightPanel)
 
-	return newModel(rootPanel, repository)
+	return newModel(rootPanel, repository, clock)
 }
 
 func main() {
+	if err := run(); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "scheduled: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs a command or the board as given by the flags. The repository is
+// closed before it returns.
+func run() error {
 	tasksFile := flag.String("f", "tasks.json", "tasks file to use")
+	store := flag.String("store", "json", "storage backend, json or sqlite")
+	serverURL := flag.String("server", "", "use the tasks of a server started with \"scheduled serve\", e.g. http://localhost:7070")
+	today := flag.String("date", "", "run as if today were the given day, e.g. 2026-10-17")
+	preset := flag.String("keys", "", "key binding preset, default or vim, overrides the preset of keys.json")
+	clipboardStrategy := flag.String("clipboard", "", "clipboard to copy to, one of "+strings.Join(clpboard.Strategies, ", ")+"; chosen from the environment if not set")
+	copyFormat := flag.String("copy", "", "clipboard format, one of "+strings.Join(clpboard.Formats(), ", ")+"; asks when copying if not set")
 	showVersion := flag.Bool("version", false, "show version")
+	flag.Usage = func() {
+		out := flag.CommandLine.Output()
+		_, _ = fmt.Fprintln(out, "usage: scheduled [flags] [command]")
+		_, _ = fmt.Fprintln(out, "\nWithout a command the board is opened.\n\nFlags:")
+		flag.PrintDefaults()
+		_, _ = fmt.Fprintln(out)
+		cli.Usage(out)
+	}
 	flag.Parse()
 
 	if *showVersion {
 		fmt.Println(version)
-		os.Exit(0)
+		return nil
+	}
+
+	clock := date.System
+	if *today != "" {
+		day, err := date.Parse(*today)
+		if err != nil {
+			return fmt.Errorf("invalid date '%s', expected YYYY-MM-DD", *today)
+		}
+		clock = date.Shifted(day)
+	}
+
+	var repo repository
+	switch {
+	case *serverURL != "":
+		repo = server.NewClient(*serverURL)
+	case *store == "json":
+		repo = file.NewRepository(*tasksFile, clock)
+	case *store == "sqlite":
+		db, err := sqlite.Open(file.Path(strings.TrimSuffix(*tasksFile, ".json") + ".db"))
+		if err != nil {
+			return err
+		}
+		defer func() { _ = db.Close() }()
+		repo = db
+	default:
+		return fmt.Errorf("unknown store '%s'", *store)
+	}
+
+	if flag.NArg() > 0 {
+		return cli.Run(flag.Args(), repo, clock, os.Stdout)
+	}
+	keyConfig, err := scheduled.LoadKeyConfig(file.Path("keys.json"))
+	if err != nil {
+		return err
+	}
+	if *preset != "" {
+		keyConfig.Preset = *preset
+	}
+	keys, contextKeys, err := keyConfig.Keys()
+	if err != nil {
+		return fmt.Errorf("invalid key bindings: %w", err)
 	}
 
-	repo := file.NewRepository(*tasksFile)
-	m := createModel(repo)
+	m, err := createModel(repo, clock)
+	if err != nil {
+		return fmt.Errorf("could not open board, refusing to start to protect your data: %w", err)
+	}
+	m = m.withKeys(keys, contextKeys, keyConfig.Preset == "vim")
+	if *copyFormat != "" {
+		if !slices.Contains(clpboard.Formats(), *copyFormat) {
+			return fmt.Errorf("unknown clipboard format '%s'", *copyFormat)
+		}
+		m.copyFormat = *copyFormat
+	}
+	if *clipboardStrategy != "" {
+		if m.clipboard, err = clpboard.Select(*clipboardStrategy, os.Getenv, os.Stderr, file.Path("clipboard.txt")); err != nil {
+			return err
+		}
+	}
 
 	p := tea.NewProgram(m, tea.WithAltScreen())
 	if _, err := p.Run(); err != nil {
-		fmt.Printf("there's been an error: %v", err)
-		os.Exit(1)
+		return fmt.Errorf("there's been an error: %w", err)
 	}
+	return nil
 }
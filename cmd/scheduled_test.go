@@ -2,7 +2,10 @@ package main
 
 import (
 	"bytes"
-	"fmt"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -10,31 +13,62 @@ import (
 	"github.com/charmbracelet/x/exp/teatest"
 	"github.com/rwirdemann/scheduled"
 	"github.com/rwirdemann/scheduled/board"
+	clpboard "github.com/rwirdemann/scheduled/clipboard"
 )
 
 // Mock repository for testing
 type mockRepository struct {
 	tasks    []scheduled.Task
 	contexts []scheduled.Context
+	archive  []scheduled.ArchivedTask
+	loadErr  error
+	saveErr  error
+
+	// modified makes saves fail until the tasks are loaded again
+	modified bool
 }
 
-func (m *mockRepository) LoadTasks() []scheduled.Task {
-	return m.tasks
+func (m *mockRepository) LoadTasks() ([]scheduled.Task, error) {
+	m.modified = false
+	return m.tasks, m.loadErr
 }
 
-func (m *mockRepository) SaveTasks(tasks []scheduled.Task) {
+func (m *mockRepository) SaveTasks(tasks []scheduled.Task) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	if m.modified {
+		return scheduled.ErrModified
+	}
 	m.tasks = tasks
+	return nil
 }
 
-func (m *mockRepository) LoadContexts() []scheduled.Context {
+func (m *mockRepository) LoadContexts() ([]scheduled.Context, error) {
 	if len(m.contexts) == 0 {
-		return []scheduled.Context{scheduled.ContextNone}
+		return []scheduled.Context{scheduled.ContextNone}, nil
 	}
-	return m.contexts
+	return m.contexts, nil
 }
 
-func (m *mockRepository) SaveContexts(contexts []scheduled.Context) {
+func (m *mockRepository) SaveContexts(contexts []scheduled.Context) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
 	m.contexts = contexts
+	return nil
+}
+
+func (m *mockRepository) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	return m.archive, nil
+}
+
+func (m *mockRepository) SaveArchive(archive []scheduled.ArchivedTask) error {
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.archive = archive
+	return nil
 }
 
 // Helper function to create a test model with a mock repository
@@ -47,7 +81,29 @@ func createTestModel(t *testing.T) model {
 		contexts: []scheduled.Context{scheduled.ContextNone},
 	}
 
-	return createModel(repo)
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatalf("createModel() error = %v", err)
+	}
+	return m
+}
+
+// testClock is a clock tests can advance. It starts on Wednesday of week 10
+// in 2026.
+type testClock struct {
+	now time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local)}
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func (c *testClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
 }
 
 func TestIntegration_InitialState(t *testing.T) {
@@ -106,8 +162,7 @@ func TestIntegration_WeekNavigation(t *testing.T) {
 	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(200, 50))
 	defer tm.Quit()
 
-	_, w := time.Now().ISOWeek()
-	expected := fmt.Sprintf("Week %d", w)
+	expected := "Week 10, 2026"
 	teatest.WaitFor(
 		t,
 		tm.Output(),
@@ -122,7 +177,7 @@ func TestIntegration_WeekNavigation(t *testing.T) {
 	tm.Send(tea.KeyMsg{Type: tea.KeyRight})
 	time.Sleep(50 * time.Millisecond)
 
-	expected = fmt.Sprintf("Week %d", w+1)
+	expected = "Week 11, 2026"
 	teatest.WaitFor(
 		t,
 		tm.Output(),
@@ -137,7 +192,7 @@ func TestIntegration_WeekNavigation(t *testing.T) {
 	tm.Send(tea.KeyMsg{Type: tea.KeyLeft})
 	time.Sleep(50 * time.Millisecond)
 
-	expected = fmt.Sprintf("Week %d", w)
+	expected = "Week 10, 2026"
 	teatest.WaitFor(
 		t,
 		tm.Output(),
@@ -241,6 +296,514 @@ func TestIntegration_StatusMessage(t *testing.T) {
 		// Status panel should exist
 		t.Log("Status message set successfully")
 	}
+
+	// The message is only cleared once it timed out
+	clock := m.clock.(*testClock)
+	clock.Advance(time.Second)
+	updated, _ := m.Update(clearStatusMsg{})
+	m = updated.(model)
+	if m.statusMessage != "Test Status" {
+		t.Errorf("Status message = %q, want it to be kept before the timeout", m.statusMessage)
+	}
+	clock.Advance(2 * time.Second)
+	updated, _ = m.Update(clearStatusMsg{})
+	m = updated.(model)
+	if m.statusMessage != "" {
+		t.Errorf("Status message = %q, want it to be cleared after the timeout", m.statusMessage)
+	}
+}
+
+func TestIntegration_ConfiguredKeys(t *testing.T) {
+	c := scheduled.KeyConfig{Board: map[string][]string{"Back": {"x"}}}
+	keys, contextKeys, err := c.Apply(scheduled.Keys, scheduled.ContextViewKeys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := createTestModel(t).withKeys(keys, contextKeys, false)
+	m.board.CreateTask(scheduled.Task{Name: "Task", Done: true})
+
+	if help := strings.Join(strings.Fields(renderHelp(m, panelHelp, 200, 10)), " "); !strings.Contains(help, "x delete task") {
+		t.Errorf("help = %q, want the configured key", help)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	m = updated.(model)
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 1 {
+		t.Fatalf("backspace should no longer delete, got %d tasks", n)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'x'}})
+	m = updated.(model)
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 0 {
+		t.Errorf("x should delete the task, got %d tasks", n)
+	}
+}
+
+func TestIntegration_VimKeys(t *testing.T) {
+	repo := &mockRepository{
+		tasks: []scheduled.Task{
+			{ID: "1", Name: "First", Pos: 0},
+			{ID: "2", Name: "Second", Pos: 1},
+			{ID: "3", Name: "Third", Pos: 2, Done: true},
+		},
+		contexts: []scheduled.Context{scheduled.ContextNone},
+	}
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = m.withKeys(scheduled.VimKeys, scheduled.ContextViewKeys, true)
+
+	send := func(keys string) {
+		for _, r := range keys {
+			updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+			m = updated.(model)
+		}
+	}
+	selected := func() string {
+		focusedPanel, _ := m.root.Focused()
+		t, _ := m.board.GetSelectedTask(focusedPanel.ID)
+		return t.Name
+	}
+
+	send("G")
+	if got := selected(); got != "Third" {
+		t.Errorf("G selected %q, want Third", got)
+	}
+	send("dd")
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 2 {
+		t.Fatalf("dd should delete the done task, got %d tasks", n)
+	}
+
+	send("gg")
+	if got := selected(); got != "First" {
+		t.Errorf("gg selected %q, want First", got)
+	}
+	send("3L")
+	if n := len(m.board.GetTasksForPanel(board.Wednesday)); n != 1 {
+		t.Errorf("3L should move the task to Wednesday, got %d tasks there", n)
+	}
+
+	send("2l")
+	if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != board.Tuesday {
+		t.Errorf("2l focused panel %d, want Tuesday", focusedPanel.ID)
+	}
+	send("l")
+	send("x")
+	if tasks := m.board.GetTasksForPanel(board.Wednesday); !tasks[0].Done {
+		t.Error("x should check the task")
+	}
+
+	// Moving a task counts as a single step of the undo history
+	send("u")
+	if tasks := m.board.GetTasksForPanel(board.Wednesday); tasks[0].Done {
+		t.Error("u should uncheck the task again")
+	}
+	send("u")
+	if n := len(m.board.GetTasksForPanel(board.Wednesday)); n != 0 {
+		t.Errorf("u should move the task back, got %d tasks on Wednesday", n)
+	}
+}
+
+func TestIntegration_MarkedTasks(t *testing.T) {
+	repo := &mockRepository{
+		tasks: []scheduled.Task{
+			{ID: "1", Name: "First", Pos: 0},
+			{ID: "2", Name: "Second", Pos: 1},
+			{ID: "3", Name: "Third", Pos: 2},
+		},
+		contexts: []scheduled.Context{scheduled.ContextNone},
+	}
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = m.withKeys(scheduled.VimKeys, scheduled.ContextViewKeys, true)
+
+	send := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			updated, _ := m.Update(msg)
+			m = updated.(model)
+		}
+	}
+	runes := func(keys string) tea.KeyMsg {
+		return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(keys)}
+	}
+
+	// Mark First and Third and move both two days ahead
+	send(runes("v"), runes("j"), runes("v"), runes("2"), runes("L"))
+	if got := len(m.board.GetTasksForPanel(board.Tuesday)); got != 2 {
+		t.Fatalf("Tuesday has %d tasks, want the 2 marked tasks", got)
+	}
+
+	send(runes("x"))
+	for _, task := range m.board.GetTasksForPanel(board.Tuesday) {
+		if !task.Done {
+			t.Errorf("%s should be checked", task.Name)
+		}
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.board.HasMarks() {
+		t.Error("esc should clear the marks")
+	}
+
+	// Bulk changes are undone at once
+	send(runes("u"), runes("u"))
+	if got := len(m.board.GetTasksForPanel(board.Inbox)); got != 3 {
+		t.Errorf("Inbox has %d tasks after undo, want 3", got)
+	}
+}
+
+func TestIntegration_Archive(t *testing.T) {
+	repo := &mockRepository{
+		tasks: []scheduled.Task{
+			{ID: "1", Name: "Done", Done: true, Context: 2},
+			{ID: "2", Name: "Open", Pos: 1},
+		},
+		contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}},
+	}
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			updated, _ := m.Update(msg)
+			m = updated.(model)
+		}
+	}
+	send(tea.KeyMsg{Type: tea.KeyBackspace}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+	if len(m.board.GetTasksForPanel(board.Inbox)) != 1 || len(m.archiveTasks) != 1 {
+		t.Fatalf("archive shows %v, want the deleted task", m.archiveTasks)
+	}
+	if !strings.Contains(renderArchive(m, archivePanel, 100, 12), "Archive (Week 10, 2026) - all contexts") {
+		t.Error("The archive should show the week of the board")
+	}
+
+	// Browse contexts and weeks
+	tests := []struct {
+		key  tea.KeyMsg
+		want string
+		n    int
+	}{
+		{tea.KeyMsg{Type: tea.KeyTab}, "none", 0},
+		{tea.KeyMsg{Type: tea.KeyTab}, "work", 1},
+		{tea.KeyMsg{Type: tea.KeyRight}, "Week 11, 2026) - work", 0},
+		{tea.KeyMsg{Type: tea.KeyLeft}, "Week 10, 2026) - work", 1},
+	}
+	for _, tt := range tests {
+		send(tt.key)
+		if !strings.Contains(renderArchive(m, archivePanel, 100, 12), tt.want) || len(m.archiveTasks) != tt.n {
+			t.Errorf("after %s archive shows %d tasks, want %q with %d tasks", tt.key, len(m.archiveTasks), tt.want, tt.n)
+		}
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if len(m.board.GetTasksForPanel(board.Inbox)) != 2 || len(m.archiveTasks) != 0 {
+		t.Error("enter should restore the task to the Inbox")
+	}
+
+	// Restoring is undone like any other change
+	send(tea.KeyMsg{Type: tea.KeyEsc}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("u")})
+	if m.mode != modeNormal || len(m.board.Archive()) != 1 {
+		t.Errorf("undo should archive the task again, archive = %v", m.board.Archive())
+	}
+}
+
+func TestIntegration_Search(t *testing.T) {
+	repo := &mockRepository{
+		tasks: []scheduled.Task{
+			{ID: "1", Name: "Buy milk"},
+			{ID: "2", Name: "Write report", Day: board.Thursday, Context: 2},
+		},
+		contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}, {ID: 3, Name: "home"}},
+	}
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.board.SetContext(scheduled.Context{ID: 3, Name: "home"})
+
+	send := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'/'}})
+	if m.mode != modeSearch {
+		t.Fatal("/ should open the search")
+	}
+	for _, r := range "rep" {
+		send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if len(m.searchResults) != 1 {
+		t.Fatalf("results = %v, want the report", m.searchResults)
+	}
+	view := renderSearch(m, searchPanel, 120, 10)
+	if !strings.Contains(view, "Write report") || !strings.Contains(view, "Thursday 05.03.2026 · work") {
+		t.Errorf("search view = %q, want day and context of the result", view)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != modeNormal {
+		t.Error("enter should close the search")
+	}
+	if focusedPanel, _ := m.root.Focused(); focusedPanel.ID != board.Thursday {
+		t.Errorf("focused panel = %d, want Thursday", focusedPanel.ID)
+	}
+	if task, _ := m.board.GetSelectedTask(board.Thursday); task.ID != "2" {
+		t.Errorf("selected task = %q, want the report", task.ID)
+	}
+	if m.board.GetSelectedContext() != scheduled.ContextNone {
+		t.Error("the context filter hiding the task should be cleared")
+	}
+}
+
+func TestIntegration_CommandPalette(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		check func(t *testing.T, m model)
+	}{
+		{"action of the key map", "toggle det", func(t *testing.T, m model) {
+			if !m.showDetails {
+				t.Error("details should be shown")
+			}
+		}},
+		{"move to a day", "move thursday", func(t *testing.T, m model) {
+			if n := len(m.board.GetTasksForPanel(board.Thursday)); n != 1 {
+				t.Errorf("got %d tasks on Thursday, want 1", n)
+			}
+		}},
+		{"switch context", "switch context work", func(t *testing.T, m model) {
+			if m.board.GetSelectedContext().Name != "work" {
+				t.Errorf("context = %v, want work", m.board.GetSelectedContext())
+			}
+		}},
+		{"go to week", "go to week 42", func(t *testing.T, m model) {
+			if m.board.Week() != 42 {
+				t.Errorf("week = %d, want 42", m.board.Week())
+			}
+		}},
+		{"context view action", "contexts: new", func(t *testing.T, m model) {
+			if m.mode != modeContexts || !m.editContextShown {
+				t.Error("the context view should be open to enter a new context")
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{
+				tasks:    []scheduled.Task{{ID: "1", Name: "Task"}},
+				contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}},
+			}
+			m, err := createModel(repo, newTestClock())
+			if err != nil {
+				t.Fatal(err)
+			}
+			send := func(msg tea.KeyMsg) {
+				updated, _ := m.Update(msg)
+				m = updated.(model)
+			}
+
+			send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{':'}})
+			if m.mode != modePalette {
+				t.Fatal(": should open the command palette")
+			}
+			send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(tt.query)})
+			if len(m.paletteMatches) == 0 {
+				t.Fatalf("no command matches %q", tt.query)
+			}
+			send(tea.KeyMsg{Type: tea.KeyEnter})
+			tt.check(t, m)
+		})
+	}
+}
+
+func TestIntegration_TodoTxt(t *testing.T) {
+	repo := &mockRepository{
+		tasks:    []scheduled.Task{{ID: "1", Name: "Task", Day: board.Friday, Context: 2}},
+		contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}},
+	}
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.todoFile = filepath.Join(t.TempDir(), "todo.txt")
+	palette := func(query string) {
+		for _, msg := range []tea.KeyMsg{{Type: tea.KeyRunes, Runes: []rune{':'}}, {Type: tea.KeyRunes, Runes: []rune(query)}, {Type: tea.KeyEnter}} {
+			updated, _ := m.Update(msg)
+			m = updated.(model)
+		}
+	}
+
+	palette("export todo.txt")
+	if data, _ := os.ReadFile(m.todoFile); string(data) != "Task @work due:2026-03-06 id:1\n" {
+		t.Errorf("exported %q", data)
+	}
+
+	// Importing the export again updates the task
+	if err := os.WriteFile(m.todoFile, []byte("Task renamed @work due:2026-03-06 id:1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	palette("import todo.txt")
+	if tasks := m.board.GetTasksForPanel(board.Friday); len(tasks) != 1 || tasks[0].Name != "Task renamed" {
+		t.Errorf("Friday = %+v, want the task to be renamed", tasks)
+	}
+
+	if err := os.WriteFile(m.todoFile, []byte("Call Anna @family\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	palette("import todo.txt")
+	if tasks := m.board.GetTasksForPanel(board.Inbox); len(tasks) != 1 || tasks[0].Context != 3 {
+		t.Errorf("Inbox = %+v, want the imported task in a new context", tasks)
+	}
+	if contexts := m.contexts(); len(contexts) != 3 || contexts[2].Name != "family" {
+		t.Errorf("contexts = %v, want family to be added", contexts)
+	}
+
+	// Undo removes the new context with the imported task, redo adds both
+	for _, step := range []struct {
+		key      tea.KeyMsg
+		contexts int
+		tasks    int
+	}{
+		{tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}}, 2, 0},
+		{tea.KeyMsg{Type: tea.KeyCtrlR}, 3, 1},
+	} {
+		updated, _ := m.Update(step.key)
+		m = updated.(model)
+		if contexts, tasks := m.contexts(), m.board.GetTasksForPanel(board.Inbox); len(contexts) != step.contexts || len(tasks) != step.tasks {
+			t.Errorf("after %s: contexts = %v, Inbox = %v", step.key, contexts, tasks)
+		}
+	}
+
+	m.calendarFile = filepath.Join(t.TempDir(), "week.ics")
+	palette("export calendar")
+	data, _ := os.ReadFile(m.calendarFile)
+	for _, line := range []string{"SUMMARY:Task renamed", "CATEGORIES:work", "DTSTART;VALUE=DATE:20260306", "SUMMARY:Call Anna"} {
+		if !strings.Contains(string(data), line+"\r\n") {
+			t.Errorf("exported %q, want it to contain %s", data, line)
+		}
+	}
+}
+
+func TestIntegration_Report(t *testing.T) {
+	m, err := createModel(&mockRepository{tasks: []scheduled.Task{{ID: "1", Name: "Task", Day: board.Friday}}}, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.templateDir = t.TempDir()
+	m.clipboard = clpboard.Chain{clpboard.File{Path: filepath.Join(m.templateDir, "clipboard.txt")}}
+	report := func() {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+		m = updated.(model)
+	}
+
+	report()
+	if want := "Report of week 10 copied to " + m.clipboard[0].Name(); m.statusMessage != want {
+		t.Errorf("Status message = %q, want %q", m.statusMessage, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(m.templateDir, "default.tmpl"), []byte("{{.Weeks"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	report()
+	if !strings.HasPrefix(m.statusMessage, "Report failed: template 'default'") {
+		t.Errorf("Status message = %q, want the template error", m.statusMessage)
+	}
+}
+
+func TestIntegration_Copy(t *testing.T) {
+	repo := &mockRepository{tasks: []scheduled.Task{
+		{ID: "1", Name: "Inbox task"},
+		{ID: "2", Name: "Tuesday task", Day: board.Tuesday},
+		{ID: "3", Name: "Standup", Day: board.Monday, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}},
+	}}
+	m, err := createModel(repo, newTestClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	clipboardFile := filepath.Join(t.TempDir(), "clipboard.txt")
+	m.clipboard = clpboard.Chain{clpboard.File{Path: clipboardFile}}
+	send := func(msgs ...tea.KeyMsg) {
+		for _, msg := range msgs {
+			updated, _ := m.Update(msg)
+			m = updated.(model)
+		}
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	if m.mode != modeCopy || len(m.copyTasks) != 1 {
+		t.Fatalf("mode = %v with %d tasks, want the copy panel for the Inbox task", m.mode, len(m.copyTasks))
+	}
+	if got := renderCopy(m, copyPanel, 40, 12); !strings.Contains(got, "Copy 1 tasks as") || !strings.Contains(got, "> summary") {
+		t.Errorf("copy panel = %q, want the formats with summary selected", got)
+	}
+	send(tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.mode != modeNormal || m.statusMessage != "1 tasks copied to "+clipboardFile+" as markdown" {
+		t.Errorf("mode = %v, status = %q, want the task copied as markdown", m.mode, m.statusMessage)
+	}
+	if data, _ := os.ReadFile(clipboardFile); string(data) != "- [ ] Inbox task\n" {
+		t.Errorf("copied %q", data)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")}, tea.KeyMsg{Type: tea.KeyEsc})
+	if m.mode != modeNormal {
+		t.Errorf("mode = %v, esc should close the copy panel", m.mode)
+	}
+
+	// The week contains the Standup on each weekday
+	m.copyFormat = "csv"
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if m.mode != modeNormal || m.statusMessage != "7 tasks copied to "+clipboardFile+" as csv" {
+		t.Errorf("mode = %v, status = %q, want the week copied as csv", m.mode, m.statusMessage)
+	}
+
+	// The Standup copied from Tuesday belongs to Tuesday
+	m.copyFormat = "markdown"
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2")}, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("k")})
+	if data, _ := os.ReadFile(clipboardFile); strings.Contains(string(data), "##") || !strings.Contains(string(data), "Standup") {
+		t.Errorf("copied %q, want the tasks of Tuesday without headings", data)
+	}
+
+	m.clipboard = clpboard.Chain{clpboard.File{Path: filepath.Join(clipboardFile, "missing")}}
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("C")})
+	if !strings.HasPrefix(m.statusMessage, "Copy failed: "+clipboardFile) {
+		t.Errorf("status = %q, want the reason copying failed", m.statusMessage)
+	}
+}
+
+func TestIntegration_MoveToToday(t *testing.T) {
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{"wednesday", time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local), board.Wednesday},
+		{"sunday", time.Date(2026, time.March, 8, 9, 0, 0, 0, time.Local), board.Sunday},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &mockRepository{
+				tasks:    []scheduled.Task{{ID: "1", Name: "Task"}},
+				contexts: []scheduled.Context{scheduled.ContextNone},
+			}
+			m, err := createModel(repo, &testClock{now: tt.now})
+			if err != nil {
+				t.Fatalf("createModel() error = %v", err)
+			}
+
+			updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'t'}})
+			m = updated.(model)
+
+			if n := len(m.board.GetTasksForPanel(tt.want)); n != 1 {
+				t.Errorf("Expected task to be moved to day %d, got %d tasks there", tt.want, n)
+			}
+		})
+	}
 }
 
 func TestIntegration_WindowResize(t *testing.T) {
@@ -270,10 +833,12 @@ func TestIntegration_SaveFunction(t *testing.T) {
 	m := createTestModel(t)
 
 	// Create a task
-	m.board.CreateTask("Test Task", 0)
+	m.board.CreateTask(scheduled.Task{Name: "Test Task"})
 
 	// Save
-	m.Save()
+	if err := m.Save(); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
 
 	// Verify task was saved via mock repository
 	repo := m.repository.(*mockRepository)
@@ -281,3 +846,131 @@ func TestIntegration_SaveFunction(t *testing.T) {
 		t.Error("Tasks should be saved in repository")
 	}
 }
+
+func TestIntegration_LoadErrorRefusesToOpen(t *testing.T) {
+	repo := &mockRepository{loadErr: errors.New("corrupted")}
+
+	if _, err := createModel(repo, newTestClock()); err == nil {
+		t.Error("createModel() should fail if tasks can't be loaded")
+	}
+}
+
+func TestIntegration_AutoSaveFailure(t *testing.T) {
+	m := createTestModel(t)
+	repo := m.repository.(*mockRepository)
+	repo.saveErr = errors.New("disk full")
+
+	updated, cmd := m.Update(autoSaveMsg{})
+	m = updated.(model)
+
+	if !strings.Contains(m.statusMessage, "disk full") {
+		t.Errorf("Status message = %q, want save error", m.statusMessage)
+	}
+	if cmd == nil {
+		t.Error("A failed save should be retried")
+	}
+
+	// Quitting doesn't discard changes on the first attempt
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'q'}})
+	m = updated.(model)
+	if !m.quitPending {
+		t.Error("Quit should be pending after a failed save")
+	}
+
+	repo.saveErr = nil
+	updated, _ = m.Update(autoSaveMsg{})
+	m = updated.(model)
+	if m.quitPending {
+		t.Error("A successful save should reset the pending quit")
+	}
+}
+
+func TestIntegration_UndoRedo(t *testing.T) {
+	m := createTestModel(t)
+	m.board.CreateTask(scheduled.Task{Name: "Task"})
+
+	send := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(model)
+	}
+
+	// Complete and delete the task
+	send(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	send(tea.KeyMsg{Type: tea.KeyBackspace})
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 0 {
+		t.Fatalf("Expected task to be deleted, got %d tasks", n)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	tasks := m.board.GetTasksForPanel(board.Inbox)
+	if len(tasks) != 1 || !tasks[0].Done {
+		t.Fatalf("Expected done task to be restored, got %v", tasks)
+	}
+	if m.statusMessage != "Undid delete 'Task'" {
+		t.Errorf("Status message = %q", m.statusMessage)
+	}
+
+	send(tea.KeyMsg{Type: tea.KeyCtrlR})
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 0 {
+		t.Errorf("Expected task to be deleted again, got %d tasks", n)
+	}
+
+	// History survives autosave
+	updated, _ := m.Update(autoSaveMsg{})
+	m = updated.(model)
+	send(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 1 {
+		t.Errorf("Expected task to be restored after autosave, got %d tasks", n)
+	}
+}
+
+func TestIntegration_ExternalChangeReloads(t *testing.T) {
+	m := createTestModel(t)
+	repo := m.repository.(*mockRepository)
+
+	// Without local changes the board follows the repository
+	repo.tasks = []scheduled.Task{{ID: "1", Name: "From server"}}
+	updated, _ := m.Update(externalChangeMsg{})
+	m = updated.(model)
+	tasks := m.board.GetTasksForPanel(board.Inbox)
+	if len(tasks) != 1 || tasks[0].Name != "From server" {
+		t.Fatalf("Expected board to be reloaded, got %v", tasks)
+	}
+
+	// Unsaved local changes are merged with the external changes
+	m.board.CreateTask(scheduled.Task{Name: "Local"})
+	repo.tasks = nil
+	updated, _ = m.Update(externalChangeMsg{})
+	m = updated.(model)
+	tasks = m.board.GetTasksForPanel(board.Inbox)
+	if len(tasks) != 1 || tasks[0].Name != "Local" {
+		t.Errorf("Expected local task to be kept and deleted task to be removed, got %v", tasks)
+	}
+
+	// The merge can be undone
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'u'}})
+	m = updated.(model)
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 2 {
+		t.Errorf("Expected merge to be undone, got %d tasks", n)
+	}
+}
+
+func TestIntegration_AutoSaveMergesExternalChanges(t *testing.T) {
+	m := createTestModel(t)
+	repo := m.repository.(*mockRepository)
+	m.board.CreateTask(scheduled.Task{Name: "Local"})
+
+	// Another program added a task since the board was loaded
+	repo.tasks = []scheduled.Task{{ID: "1", Name: "External"}}
+	repo.modified = true
+
+	updated, _ := m.Update(autoSaveMsg{})
+	m = updated.(model)
+
+	if len(repo.tasks) != 2 {
+		t.Errorf("Expected both tasks to be saved, got %v", repo.tasks)
+	}
+	if n := len(m.board.GetTasksForPanel(board.Inbox)); n != 2 {
+		t.Errorf("Expected both tasks on the board, got %d", n)
+	}
+}
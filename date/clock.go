@@ -0,0 +1,53 @@
+package date
+
+import (
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+// Clock tells the current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is the clock of the operating system.
+var System Clock = systemClock{}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// Fixed is a clock that always tells the same time.
+type Fixed time.Time
+
+// Now returns the fixed time.
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
+
+// shiftedClock runs like the system clock, but the given number of days ahead
+// or behind.
+type shiftedClock struct {
+	days int
+}
+
+// Shifted returns a clock that runs like the system clock, but on the given
+// day. The time of day is taken from the system clock.
+func Shifted(day time.Time) Clock {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	target := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	return shiftedClock{days: int(target.Sub(today).Hours() / 24)}
+}
+
+func (c shiftedClock) Now() time.Time {
+	return time.Now().AddDate(0, 0, c.days)
+}
+
+// Parse parses a date like 2026-10-17 in the local time zone.
+func Parse(s string) (time.Time, error) {
+	return time.ParseInLocation(scheduled.DateFormat, s, time.Local)
+}
@@ -2,22 +2,19 @@ package date
 
 import "time"
 
-// GetMondayOfWeek returns the Monday of the given week
-func GetMondayOfWeek(week int) time.Time {
-	now := time.Now()
-
-	// Get January 1 of the current year
-	startOfYear := time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
-
-	// Calculate the first Monday on or after January 1
-	firstMonday := startOfYear
-	for firstMonday.Weekday() != time.Monday {
-		firstMonday = firstMonday.AddDate(0, 0, 1)
-	}
-
-	// Add the offset for the given week (weeks start from 0)
-	daysToAdd := (week - 2) * 7
-	mondayOfWeek := firstMonday.AddDate(0, 0, daysToAdd)
+// GetMondayOfWeek returns the Monday of the given ISO 8601 week of the given
+// year. Week 1 is the week containing January 4th, so it may start in the
+// previous year.
+func GetMondayOfWeek(year, week int) time.Time {
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.Local)
+	daysSinceMonday := (int(jan4.Weekday()) + 6) % 7
+	return jan4.AddDate(0, 0, (week-1)*7-daysSinceMonday)
+}
 
-	return mondayOfWeek
+// WeeksInYear returns the number of ISO 8601 weeks of the given year, which
+// is either 52 or 53.
+func WeeksInYear(year int) int {
+	// December 28th is always in the last week of the year
+	_, week := time.Date(year, time.December, 28, 0, 0, 0, 0, time.Local).ISOWeek()
+	return week
 }
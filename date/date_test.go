@@ -0,0 +1,73 @@
+package date
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+func TestGetMondayOfWeek(t *testing.T) {
+	tests := []struct {
+		name string
+		year int
+		week int
+		want string
+	}{
+		{"first week of 2020 starts in 2019", 2020, 1, "2019-12-30"},
+		{"week 10 of 2020", 2020, 10, "2020-03-02"},
+		{"week 53 of 2020", 2020, 53, "2020-12-28"},
+		{"first week of 2021 starts after new year", 2021, 1, "2021-01-04"},
+		{"week 52 of 2021", 2021, 52, "2021-12-27"},
+		{"first week of 2026 starts in 2025", 2026, 1, "2025-12-29"},
+		{"week 10 of 2026", 2026, 10, "2026-03-02"},
+		{"week 53 of 2026", 2026, 53, "2026-12-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GetMondayOfWeek(tt.year, tt.week)
+			if got.Format("2006-01-02") != tt.want {
+				t.Errorf("GetMondayOfWeek(%d, %d) = %s, want %s", tt.year, tt.week, got.Format("2006-01-02"), tt.want)
+			}
+			if got.Weekday() != time.Monday {
+				t.Errorf("GetMondayOfWeek(%d, %d) is a %s", tt.year, tt.week, got.Weekday())
+			}
+			if year, week := got.ISOWeek(); year != tt.year || week != tt.week {
+				t.Errorf("ISOWeek() = %d/%d, want %d/%d", year, week, tt.year, tt.week)
+			}
+		})
+	}
+}
+
+func TestWeeksInYear(t *testing.T) {
+	tests := []struct {
+		year int
+		want int
+	}{
+		{2019, 52},
+		{2020, 53},
+		{2021, 52},
+		{2025, 52},
+		{2026, 53},
+		{2027, 52},
+	}
+
+	for _, tt := range tests {
+		if got := WeeksInYear(tt.year); got != tt.want {
+			t.Errorf("WeeksInYear(%d) = %d, want %d", tt.year, got, tt.want)
+		}
+	}
+}
+
+func TestShifted(t *testing.T) {
+	for _, day := range []string{"2020-12-31", "2026-01-01", "2026-10-17"} {
+		d, err := Parse(day)
+		if err != nil {
+			t.Fatalf("Parse(%s) error = %v", day, err)
+		}
+		if got := Shifted(d).Now().Format(scheduled.DateFormat); got != day {
+			t.Errorf("Shifted(%s).Now() = %s", day, got)
+		}
+	}
+}
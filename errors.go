@@ -0,0 +1,7 @@
+package scheduled
+
+import "errors"
+
+// ErrModified is returned by repositories if the tasks, contexts or archive
+// to be saved were changed by another program since they were last loaded.
+var ErrModified = errors.New("modified by another program")
@@ -0,0 +1,192 @@
+package file
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	backupDir       = "backups"
+	backupTimestamp = "20060102-150405.000"
+
+	// maxBackups is the number of backups kept per file.
+	maxBackups = 10
+
+	// backupInterval is the minimum time between two backups of a file. Saves
+	// happen every few seconds, so backing up each of them would quickly
+	// rotate out all useful history.
+	backupInterval = 15 * time.Minute
+)
+
+// writeJSON replaces the file with the JSON encoding of data. The data is
+// written to a temporary file in the same directory, synced to disk and
+// renamed, so readers never see a partially written file. The previous
+// content is kept as a timestamped backup. It returns the fingerprint of the
+// written content.
+func writeJSON(filename string, data any) (fingerprint, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
+		return fingerprint{}, fmt.Errorf("failed to encode %s: %w", filename, err)
+	}
+
+	tmp, err := os.CreateTemp(base, filename+".*.tmp")
+	if err != nil {
+		return fingerprint{}, err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fingerprint{}, err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fingerprint{}, err
+	}
+	info, err := tmp.Stat()
+	if err != nil {
+		_ = tmp.Close()
+		return fingerprint{}, err
+	}
+	if err := tmp.Close(); err != nil {
+		return fingerprint{}, err
+	}
+
+	if err := backup(filename); err != nil {
+		log.Printf("Failed to back up %s: %v", filename, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path.Join(base, filename)); err != nil {
+		return fingerprint{}, err
+	}
+	syncDir(base)
+	return newFingerprint(info, buf.Bytes()), nil
+}
+
+// readJSON decodes the content of the file into v and returns the fingerprint
+// of the decoded content.
+func readJSON(filename string, v any) (fingerprint, error) {
+	data, f, err := readFile(filename)
+	if err != nil {
+		return fingerprint{}, err
+	}
+	return f, json.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// loadJSON decodes the file with the given name. If the file exists but can't
+// be decoded, the newest backup that can be decoded is returned instead. The
+// fingerprint is the one of the file, also if a backup is returned.
+func loadJSON[T any](filename string) (T, fingerprint, error) {
+	var v T
+	f, err := readJSON(filename, &v)
+	if err == nil || os.IsNotExist(err) {
+		return v, f, err
+	}
+
+	log.Printf("Failed to decode %s: %v", filename, err)
+	for _, b := range backups(filename) {
+		var recovered T
+		if _, err := readJSON(path.Join(backupDir, b), &recovered); err == nil {
+			log.Printf("Recovered %s from backup %s", filename, b)
+			return recovered, f, nil
+		}
+	}
+	return v, f, err
+}
+
+// backup copies the current content of the file to the backup directory if
+// it is valid JSON and the newest backup is older than backupInterval. Old
+// backups are removed so that at most maxBackups remain.
+func backup(filename string) error {
+	data, err := os.ReadFile(path.Join(base, filename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !json.Valid(data) {
+		return nil
+	}
+
+	existing := backups(filename)
+	if len(existing) > 0 {
+		if t, err := backupTime(filename, existing[0]); err == nil && time.Since(t) < backupInterval {
+			return nil
+		}
+	}
+
+	dir := path.Join(base, backupDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.%s.bak", filename, time.Now().Format(backupTimestamp))
+	if err := os.WriteFile(path.Join(dir, name), data, 0644); err != nil {
+		return err
+	}
+
+	all := backups(filename)
+	for i := maxBackups; i < len(all); i++ {
+		_ = os.Remove(path.Join(dir, all[i]))
+	}
+	return nil
+}
+
+// backupVersion copies the file to the backup directory before it is upgraded
+// from the given format version. These backups are not rotated.
+func backupVersion(filename string, version int) error {
+	data, err := os.ReadFile(path.Join(base, filename))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	dir := path.Join(base, backupDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s.v%d.%s.bak", filename, version, time.Now().Format(backupTimestamp))
+	return os.WriteFile(path.Join(dir, name), data, 0644)
+}
+
+// backups returns the names of all backups of the file, newest first.
+func backups(filename string) []string {
+	matches, _ := filepath.Glob(path.Join(base, backupDir, filename+".*.bak"))
+	var names []string
+	for _, m := range matches {
+		name := filepath.Base(m)
+		if _, err := backupTime(filename, name); err == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names
+}
+
+func backupTime(filename, backup string) (time.Time, error) {
+	ts := strings.TrimSuffix(strings.TrimPrefix(backup, filename+"."), ".bak")
+	return time.ParseInLocation(backupTimestamp, ts, time.Local)
+}
+
+// syncDir flushes the directory entry of a renamed file to disk. Not all
+// platforms support syncing directories, so errors are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	_ = d.Sync()
+	_ = d.Close()
+}
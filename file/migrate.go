@@ -0,0 +1,110 @@
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+// ErrNewerVersion is returned when loading a file that was written by a newer
+// version of scheduled.
+var ErrNewerVersion = errors.New("file was written by a newer version of scheduled")
+
+// document is the raw content of a tasks or contexts file.
+type document map[string]json.RawMessage
+
+// migration upgrades a document by one format version. now is the time of the
+// upgrade.
+type migration func(doc document, now time.Time) error
+
+// taskMigrations upgrade the tasks file. Migration i upgrades version i to
+// version i+1, so the current version is the number of migrations. Files
+// without a version have version 0.
+var taskMigrations = []migration{
+	migrateDoneFlag,
+}
+
+// contextMigrations upgrade the contexts file like taskMigrations.
+var contextMigrations = []migration{
+	addVersion,
+}
+
+// archiveMigrations upgrade the archive file like taskMigrations.
+var archiveMigrations = []migration{
+	addVersion,
+}
+
+// addVersion is the migration to the first versioned format, which only adds
+// the version itself.
+func addVersion(doc document, now time.Time) error {
+	return nil
+}
+
+// migrateDoneFlag converts the single done flag of rolling tasks into the
+// list of weeks the task was completed in. The flag is taken for the current
+// week.
+func migrateDoneFlag(doc document, now time.Time) error {
+	raw, ok := doc["tasks"]
+	if !ok {
+		return nil
+	}
+	var tasks []map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &tasks); err != nil {
+		return err
+	}
+
+	week, err := json.Marshal([]string{scheduled.WeekKey(now)})
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		var day int
+		var done bool
+		_ = json.Unmarshal(t["day"], &day)
+		_ = json.Unmarshal(t["done"], &done)
+		_, pinned := t["date"]
+		_, migrated := t["done_weeks"]
+		if day != 0 && done && !pinned && !migrated {
+			t["done_weeks"] = week
+			t["done"] = json.RawMessage("false")
+		}
+	}
+
+	if doc["tasks"], err = json.Marshal(tasks); err != nil {
+		return err
+	}
+	return nil
+}
+
+// upgrade runs all migrations the document needs to reach the current
+// version and returns true if it did. The original file is backed up before.
+func upgrade(filename string, doc document, migrations []migration, now time.Time) (bool, error) {
+	version := 0
+	if raw, ok := doc["version"]; ok {
+		if err := json.Unmarshal(raw, &version); err != nil {
+			return false, fmt.Errorf("invalid version: %w", err)
+		}
+	}
+	current := len(migrations)
+	if version > current {
+		return false, fmt.Errorf("%w: version %d, supported up to %d", ErrNewerVersion, version, current)
+	}
+	if version == current {
+		return false, nil
+	}
+
+	if err := backupVersion(filename, version); err != nil {
+		return false, fmt.Errorf("failed to back up before upgrading: %w", err)
+	}
+	for i, m := range migrations[version:] {
+		if err := m(doc, now); err != nil {
+			return false, fmt.Errorf("failed to upgrade to version %d: %w", version+i+1, err)
+		}
+	}
+	doc["version"] = json.RawMessage(strconv.Itoa(current))
+	return true, nil
+}
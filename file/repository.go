@@ -2,6 +2,7 @@ package file
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"path"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
 )
 
 var base string
@@ -27,123 +29,194 @@ func init() {
 	}
 }
 
+// Path returns the path of the file with the given name in the directory
+// that holds the task files.
+func Path(name string) string {
+	return path.Join(base, name)
+}
+
 // Repository stores tasks and contexts in JSON files.
 type Repository struct {
 	filenameTasks    string
 	filenameContexts string
+	filenameArchive  string
+	versions         *versions
+	clock            date.Clock
 }
 
-// NewRepository creates a new Repository instance.
-func NewRepository(filenameTasks string) Repository {
+// NewRepository creates a new Repository instance. The clock tells the week
+// older files are upgraded in.
+func NewRepository(filenameTasks string, clock date.Clock) Repository {
 	if filenameTasks == "" {
 		filenameTasks = "tasks.json"
 	}
-	filenameContexts := strings.TrimSuffix(filenameTasks, ".json") + ".contexts.json"
-	return Repository{filenameTasks: filenameTasks, filenameContexts: filenameContexts}
+	name := strings.TrimSuffix(filenameTasks, ".json")
+	return Repository{
+		filenameTasks:    filenameTasks,
+		filenameContexts: name + ".contexts.json",
+		filenameArchive:  name + ".archive.json",
+		versions:         newVersions(),
+		clock:            clock,
+	}
 }
 
-// LoadContexts loads and returns all contexts from the repository file.
-func (t Repository) LoadContexts() []scheduled.Context {
-	file, err := os.Open(path.Join(base, t.filenameContexts))
-	if err != nil {
-		return []scheduled.Context{scheduled.ContextNone}
-	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
+// Changes returns a channel that receives a value whenever the tasks,
+// contexts or archive file was changed by another program. The files are
+// polled every few seconds.
+func (t Repository) Changes() <-chan struct{} {
+	t.versions.once.Do(func() {
+		go t.versions.poll(t.filenameTasks, t.filenameContexts, t.filenameArchive)
+	})
+	return t.versions.changes
+}
 
-	var contexts struct {
-		Contexts []scheduled.Context `json:"contexts"`
+// LoadContexts loads and returns all contexts from the repository file. A
+// missing file yields the none context only.
+func (t Repository) LoadContexts() ([]scheduled.Context, error) {
+	doc, err := t.load(t.filenameContexts, contextMigrations)
+	if os.IsNotExist(err) {
+		return []scheduled.Context{scheduled.ContextNone}, nil
 	}
-
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&contexts); err != nil {
-		return []scheduled.Context{scheduled.ContextNone}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", t.filenameContexts, err)
+	}
+	var contexts []scheduled.Context
+	if err := decode(doc, "contexts", &contexts); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", t.filenameContexts, err)
 	}
 
 	// add hard coded none context
 	allContexts := []scheduled.Context{scheduled.ContextNone}
-	for _, c := range contexts.Contexts {
+	for _, c := range contexts {
 		if c.ID != scheduled.ContextNone.ID {
 			allContexts = append(allContexts, c)
 		}
 	}
 
-	return allContexts
+	return allContexts, nil
 }
 
-// LoadTasks loads and returns all tasks from the repository file.
-func (t Repository) LoadTasks() []scheduled.Task {
-	file, err := os.Open(path.Join(base, t.filenameTasks))
+// LoadTasks loads and returns all tasks from the repository file. A corrupted
+// file is recovered from the newest valid backup, a missing file yields no
+// tasks.
+func (t Repository) LoadTasks() ([]scheduled.Task, error) {
+	doc, err := t.load(t.filenameTasks, taskMigrations)
+	if os.IsNotExist(err) {
+		return []scheduled.Task{}, nil
+	}
 	if err != nil {
-		return []scheduled.Task{}
+		return nil, fmt.Errorf("failed to load %s: %w", t.filenameTasks, err)
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
-
-	var tasks struct {
-		Tasks []scheduled.Task `json:"tasks"`
+	tasks := []scheduled.Task{}
+	if err := decode(doc, "tasks", &tasks); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", t.filenameTasks, err)
 	}
 
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&tasks); err != nil {
-		log.Printf("Failed to decode %s: %v", t.filenameTasks, err)
-		return []scheduled.Task{}
-	}
-
-	for i := range tasks.Tasks {
-		if tasks.Tasks[i].ID == "" {
-			tasks.Tasks[i].ID = uuid.NewString()
+	for i := range tasks {
+		if tasks[i].ID == "" {
+			tasks[i].ID = uuid.NewString()
 		}
 	}
 
-	return tasks.Tasks
+	return tasks, nil
 }
 
-// SaveTasks saves the given tasks to the repository file.
-func (t Repository) SaveTasks(tasks []scheduled.Task) {
-	file, err := os.Create(path.Join(base, t.filenameTasks))
+// load reads the file and upgrades it to the current format version. Files
+// in an older format are backed up and rewritten in the current one, files
+// in a newer format are refused.
+func (t Repository) load(filename string, migrations []migration) (document, error) {
+	doc, f, err := loadJSON[document](filename)
+	if f.exists || os.IsNotExist(err) {
+		t.versions.remember(filename, f)
+	}
 	if err != nil {
-		log.Fatalf("Failed to create %s: %v", t.filenameTasks, err)
+		return nil, err
+	}
+	upgraded, err := upgrade(filename, doc, migrations, t.clock.Now())
+	if err != nil || !upgraded {
+		return doc, err
+	}
+	if err := t.versions.save(filename, doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// decode decodes the value with the given key of the document into v. Missing
+// keys leave v unchanged.
+func decode(doc document, key string, v any) error {
+	raw, ok := doc[key]
+	if !ok || string(raw) == "null" {
+		return nil
 	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
+	return json.Unmarshal(raw, v)
+}
 
+// SaveTasks saves the given tasks to the repository file.
+func (t Repository) SaveTasks(tasks []scheduled.Task) error {
 	data := struct {
-		Tasks []scheduled.Task `json:"tasks"`
+		Version int              `json:"version"`
+		Tasks   []scheduled.Task `json:"tasks"`
 	}{
-		Tasks: tasks,
+		Version: len(taskMigrations),
+		Tasks:   tasks,
 	}
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(data); err != nil {
-		log.Fatalf("Failed to encode tasks to %s: %v", t.filenameTasks, err)
+	if err := t.versions.save(t.filenameTasks, data); err != nil {
+		return fmt.Errorf("failed to save %s: %w", t.filenameTasks, err)
 	}
+	return nil
 }
 
 // SaveContexts saves the given contexts to the repository file.
-func (t Repository) SaveContexts(contexts []scheduled.Context) {
-	file, err := os.Create(path.Join(base, t.filenameContexts))
-	if err != nil {
-		log.Fatalf("Failed to create %s: %v", t.filenameContexts, err)
-	}
-	defer func(file *os.File) {
-		_ = file.Close()
-	}(file)
-
+func (t Repository) SaveContexts(contexts []scheduled.Context) error {
 	data := struct {
+		Version  int                 `json:"version"`
 		Contexts []scheduled.Context `json:"contexts"`
-	}{}
+	}{
+		Version: len(contextMigrations),
+	}
 	for _, c := range contexts {
 		if c.ID != 1 {
 			data.Contexts = append(data.Contexts, c)
 		}
 	}
 
-	encoder := json.NewEncoder(file)
-	if err := encoder.Encode(data); err != nil {
-		log.Fatalf("Failed to encode contexts to %s: %v", t.filenameContexts, err)
+	if err := t.versions.save(t.filenameContexts, data); err != nil {
+		return fmt.Errorf("failed to save %s: %w", t.filenameContexts, err)
+	}
+	return nil
+}
+
+// LoadArchive loads and returns all archived tasks from the archive file. A
+// missing file yields an empty archive.
+func (t Repository) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	doc, err := t.load(t.filenameArchive, archiveMigrations)
+	if os.IsNotExist(err) {
+		return []scheduled.ArchivedTask{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", t.filenameArchive, err)
+	}
+	archive := []scheduled.ArchivedTask{}
+	if err := decode(doc, "archive", &archive); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", t.filenameArchive, err)
+	}
+	return archive, nil
+}
+
+// SaveArchive saves the given archived tasks to the archive file.
+func (t Repository) SaveArchive(archive []scheduled.ArchivedTask) error {
+	data := struct {
+		Version int                      `json:"version"`
+		Archive []scheduled.ArchivedTask `json:"archive"`
+	}{
+		Version: len(archiveMigrations),
+		Archive: archive,
+	}
+
+	if err := t.versions.save(t.filenameArchive, data); err != nil {
+		return fmt.Errorf("failed to save %s: %w", t.filenameArchive, err)
 	}
+	return nil
 }
@@ -0,0 +1,337 @@
+package file
+
+import (
+	"errors"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+)
+
+// testClock is on Wednesday of week 10 in 2026.
+var testClock = date.Fixed(time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local))
+
+// useTempBase points the repository to a temporary directory for the duration
+// of the test.
+func useTempBase(t *testing.T) {
+	t.Helper()
+	old := base
+	base = t.TempDir()
+	t.Cleanup(func() { base = old })
+}
+
+func TestRepository_SaveAndLoadTasks(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	pinned := scheduled.Task{ID: "2", Name: "Pinned", Day: 3, Date: "2026-10-14"}
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Rolling", Day: 1}, pinned}); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+
+	tasks, err := r.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	if len(tasks) != 2 {
+		t.Fatalf("Expected 2 tasks, got %d", len(tasks))
+	}
+	if tasks[1].Date != pinned.Date {
+		t.Errorf("Date = %q, want %q", tasks[1].Date, pinned.Date)
+	}
+
+	entries, _ := os.ReadDir(base)
+	for _, e := range entries {
+		if path.Ext(e.Name()) == ".tmp" {
+			t.Errorf("Temporary file %s should have been removed", e.Name())
+		}
+	}
+}
+
+func TestRepository_BackupRotation(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	r.SaveTasks([]scheduled.Task{{ID: "1", Name: "First"}})
+	r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Second"}})
+	if n := len(backups("tasks.json")); n != 1 {
+		t.Fatalf("Expected 1 backup, got %d", n)
+	}
+
+	// Saves within the backup interval don't create new backups
+	r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Third"}})
+	if n := len(backups("tasks.json")); n != 1 {
+		t.Fatalf("Expected 1 backup, got %d", n)
+	}
+
+	// Fake old backups to exceed the limit
+	dir := path.Join(base, backupDir)
+	for i := 1; i <= maxBackups+2; i++ {
+		ts := time.Now().Add(-time.Duration(i) * time.Hour).Format(backupTimestamp)
+		if err := os.WriteFile(path.Join(dir, "tasks.json."+ts+".bak"), []byte(`{"tasks":[]}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old := backups("tasks.json")[0]
+	if err := os.Remove(path.Join(dir, old)); err != nil {
+		t.Fatal(err)
+	}
+	r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Fourth"}})
+
+	if n := len(backups("tasks.json")); n != maxBackups {
+		t.Errorf("Expected %d backups, got %d", maxBackups, n)
+	}
+}
+
+func TestRepository_LoadTasksRecoversFromBackup(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Saved"}})
+	r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Saved"}, {ID: "2", Name: "Newer"}})
+
+	// Simulate a truncated write
+	if err := os.WriteFile(path.Join(base, "tasks.json"), []byte(`{"tasks":[{"id":"1","na`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tasks, err := r.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].Name != "Saved" {
+		t.Errorf("Expected tasks to be recovered from backup, got %v", tasks)
+	}
+}
+
+func TestRepository_LoadTasksMissingFile(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	tasks, err := r.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	if len(tasks) != 0 {
+		t.Errorf("Expected no tasks, got %d", len(tasks))
+	}
+
+	contexts, err := r.LoadContexts()
+	if err != nil {
+		t.Fatalf("LoadContexts() error = %v", err)
+	}
+	if len(contexts) != 1 || contexts[0] != scheduled.ContextNone {
+		t.Errorf("Expected only the none context, got %v", contexts)
+	}
+}
+
+func TestRepository_LoadTasksCorruptedWithoutBackup(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	if err := os.WriteFile(path.Join(base, "tasks.json"), []byte(`{"tasks":[`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.LoadTasks(); err == nil {
+		t.Error("LoadTasks() should fail for a corrupted file without backups")
+	}
+}
+
+func TestRepository_SaveTasksError(t *testing.T) {
+	useTempBase(t)
+	base = path.Join(base, "missing")
+	r := NewRepository("tasks.json", testClock)
+
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Task"}}); err == nil {
+		t.Error("SaveTasks() should fail if the directory does not exist")
+	}
+}
+
+func TestRepository_SaveTasksRefusesExternalChanges(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+	if _, err := r.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Mine"}}); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+
+	// Another instance writes the file
+	other := NewRepository("tasks.json", testClock)
+	if _, err := other.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SaveTasks([]scheduled.Task{{ID: "2", Name: "Theirs"}}); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+
+	if !r.versions.modified("tasks.json") {
+		t.Error("External change should be detected")
+	}
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Mine again"}}); !errors.Is(err, scheduled.ErrModified) {
+		t.Fatalf("SaveTasks() error = %v, want ErrModified", err)
+	}
+
+	// Loading again makes the change known
+	if _, err := r.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Merged"}}); err != nil {
+		t.Errorf("SaveTasks() error = %v", err)
+	}
+}
+
+func TestRepository_SaveTasksWaitsForLock(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	lockFile := path.Join(base, "tasks.json.lock")
+	if err := os.WriteFile(lockFile, []byte("4711"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_ = os.Remove(lockFile)
+	}()
+
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Task"}}); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+	if _, err := os.Stat(lockFile); !os.IsNotExist(err) {
+		t.Error("Lock file should be removed after saving")
+	}
+}
+
+func TestRepository_SaveTasksRemovesStaleLock(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	lockFile := path.Join(base, "tasks.json.lock")
+	if err := os.WriteFile(lockFile, []byte("4711"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * staleLock)
+	if err := os.Chtimes(lockFile, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Task"}}); err != nil {
+		t.Errorf("SaveTasks() error = %v", err)
+	}
+}
+
+func TestRepository_LoadTasksUpgradesLegacyFile(t *testing.T) {
+	useTempBase(t)
+	legacy := `{"tasks":[{"id":"1","name":"Rolling","day":1,"done":true},{"id":"2","name":"Inbox","day":0,"done":true}]}`
+	if err := os.WriteFile(path.Join(base, "tasks.json"), []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := NewRepository("tasks.json", testClock)
+	tasks, err := r.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+
+	// The done flag of rolling tasks is kept for the current week
+	if tasks[0].Done || len(tasks[0].DoneWeeks) != 1 || tasks[0].DoneWeeks[0] != "2026-W10" {
+		t.Errorf("Rolling task = %+v, want done in the current week", tasks[0])
+	}
+	if !tasks[1].Done {
+		t.Error("Inbox task should stay done")
+	}
+
+	// The old file is backed up and the file is rewritten in the current format
+	backup, _ := filepath.Glob(path.Join(base, backupDir, "tasks.json.v0.*.bak"))
+	if len(backup) != 1 {
+		t.Fatalf("Expected a backup of the old file, got %v", backup)
+	}
+	if data, _ := os.ReadFile(backup[0]); string(data) != legacy {
+		t.Errorf("Backup = %s, want original content", data)
+	}
+	var doc struct {
+		Version int `json:"version"`
+	}
+	if _, err := readJSON("tasks.json", &doc); err != nil || doc.Version != len(taskMigrations) {
+		t.Errorf("Version = %d, %v, want %d", doc.Version, err, len(taskMigrations))
+	}
+
+	// Loading again doesn't upgrade twice
+	if _, err := r.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if backup, _ := filepath.Glob(path.Join(base, backupDir, "tasks.json.v0.*.bak")); len(backup) != 1 {
+		t.Errorf("Expected a single upgrade backup, got %v", backup)
+	}
+}
+
+func TestRepository_LoadRefusesNewerVersion(t *testing.T) {
+	useTempBase(t)
+	tests := []struct {
+		filename string
+		content  string
+		load     func(r Repository) error
+	}{
+		{"tasks.json", `{"version":99,"tasks":[]}`, func(r Repository) error { _, err := r.LoadTasks(); return err }},
+		{"tasks.contexts.json", `{"version":99,"contexts":[]}`, func(r Repository) error { _, err := r.LoadContexts(); return err }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filename, func(t *testing.T) {
+			if err := os.WriteFile(path.Join(base, tt.filename), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := tt.load(NewRepository("tasks.json", testClock)); !errors.Is(err, ErrNewerVersion) {
+				t.Errorf("Load error = %v, want ErrNewerVersion", err)
+			}
+			if data, _ := os.ReadFile(path.Join(base, tt.filename)); string(data) != tt.content {
+				t.Error("A file of a newer version must not be changed")
+			}
+		})
+	}
+}
+
+func TestRepository_SaveContextsWritesVersion(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+	if err := r.SaveContexts([]scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version int `json:"version"`
+	}
+	if _, err := readJSON("tasks.contexts.json", &doc); err != nil || doc.Version != len(contextMigrations) {
+		t.Errorf("Version = %d, %v, want %d", doc.Version, err, len(contextMigrations))
+	}
+	if contexts, err := r.LoadContexts(); err != nil || len(contexts) != 2 {
+		t.Errorf("LoadContexts() = %v, %v", contexts, err)
+	}
+}
+
+func TestRepository_SaveAndLoadArchive(t *testing.T) {
+	useTempBase(t)
+	r := NewRepository("tasks.json", testClock)
+
+	archive, err := r.LoadArchive()
+	if err != nil || len(archive) != 0 {
+		t.Fatalf("LoadArchive() = %v, %v, want empty archive", archive, err)
+	}
+
+	want := []scheduled.ArchivedTask{scheduled.Archive(scheduled.Task{ID: "1", Name: "Done", Done: true}, time.Now())}
+	if err := r.SaveArchive(want); err != nil {
+		t.Fatalf("SaveArchive() error = %v", err)
+	}
+	archive, err = r.LoadArchive()
+	if err != nil || len(archive) != 1 || archive[0].Task.ID != "1" || !archive[0].Completed.Equal(want[0].Completed) {
+		t.Errorf("LoadArchive() = %v, %v, want %v", archive, err, want)
+	}
+	if _, err := os.Stat(path.Join(base, "tasks.archive.json")); err != nil {
+		t.Errorf("archive file not written: %v", err)
+	}
+}
@@ -0,0 +1,201 @@
+package file
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+const (
+	// pollInterval is the time between two checks for changes made to the
+	// files by other programs.
+	pollInterval = 2 * time.Second
+
+	// lockTimeout is the time a save waits for the lock held by another
+	// program before it gives up.
+	lockTimeout = 3 * time.Second
+
+	// staleLock is the age after which a lock is considered to be left over
+	// by a crashed program and removed.
+	staleLock = 30 * time.Second
+)
+
+// fingerprint identifies the content of a file.
+type fingerprint struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+	hash    [sha256.Size]byte
+}
+
+// versions remembers the fingerprints of the files as last loaded or saved by
+// the repository.
+type versions struct {
+	mu       sync.Mutex
+	known    map[string]fingerprint
+	reported map[string]fingerprint
+
+	once    sync.Once
+	changes chan struct{}
+}
+
+func newVersions() *versions {
+	return &versions{
+		known:    make(map[string]fingerprint),
+		reported: make(map[string]fingerprint),
+		changes:  make(chan struct{}, 1),
+	}
+}
+
+// newFingerprint returns the fingerprint of the given content of a file.
+func newFingerprint(info os.FileInfo, data []byte) fingerprint {
+	return fingerprint{exists: true, modTime: info.ModTime(), size: int64(len(data)), hash: sha256.Sum256(data)}
+}
+
+// readFile returns the content of the file together with its fingerprint,
+// which is taken from the same open file, so that both match even if the file
+// is replaced in the meantime.
+func readFile(filename string) ([]byte, fingerprint, error) {
+	file, err := os.Open(path.Join(base, filename))
+	if err != nil {
+		return nil, fingerprint{}, err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fingerprint{}, err
+	}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fingerprint{}, err
+	}
+	return data, newFingerprint(info, data), nil
+}
+
+// read returns the fingerprint of the file. The content is only hashed if
+// modification time or size differ from the given previous fingerprint.
+func read(filename string, previous fingerprint) (fingerprint, error) {
+	info, err := os.Stat(path.Join(base, filename))
+	if os.IsNotExist(err) {
+		return fingerprint{}, nil
+	}
+	if err != nil {
+		return fingerprint{}, err
+	}
+	if previous.exists && info.ModTime().Equal(previous.modTime) && info.Size() == previous.size {
+		return previous, nil
+	}
+	_, f, err := readFile(filename)
+	if os.IsNotExist(err) {
+		return fingerprint{}, nil
+	}
+	return f, err
+}
+
+// remember records the fingerprint of the file as loaded or saved as the one
+// known to the repository.
+func (v *versions) remember(filename string, f fingerprint) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.known[filename] = f
+	delete(v.reported, filename)
+}
+
+// modified returns true if the content of the file differs from the one last
+// loaded or saved. Files the repository has never seen are not modified.
+func (v *versions) modified(filename string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	known, ok := v.known[filename]
+	if !ok {
+		return false
+	}
+	current, err := read(filename, known)
+	if err != nil {
+		return false
+	}
+	return current.exists != known.exists || current.hash != known.hash
+}
+
+// poll checks the files for changes every pollInterval and reports each new
+// version of a file once.
+func (v *versions) poll(filenames ...string) {
+	for range time.Tick(pollInterval) {
+		for _, filename := range filenames {
+			if !v.modified(filename) {
+				continue
+			}
+			v.mu.Lock()
+			current, _ := read(filename, v.reported[filename])
+			isNew := current != v.reported[filename]
+			v.reported[filename] = current
+			v.mu.Unlock()
+
+			if isNew {
+				select {
+				case v.changes <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// lock creates a lock file next to the file, which prevents other programs
+// using a repository from writing the file at the same time. The returned
+// function removes the lock.
+func lock(filename string) (func(), error) {
+	name := path.Join(base, filename+".lock")
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		f, err := os.OpenFile(name, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, _ = f.WriteString(strconv.Itoa(os.Getpid()))
+			_ = f.Close()
+			return func() { _ = os.Remove(name) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		if info, err := os.Stat(name); err == nil && time.Since(info.ModTime()) > staleLock {
+			_ = os.Remove(name)
+			continue
+		}
+		if time.Now().After(deadline) {
+			owner, _ := os.ReadFile(name)
+			return nil, fmt.Errorf("%s is locked by process %s", filename, owner)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// save writes data to the file unless it was modified by another program
+// since it was last loaded or saved.
+func (v *versions) save(filename string, data any) error {
+	unlock, err := lock(filename)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if v.modified(filename) {
+		return scheduled.ErrModified
+	}
+	f, err := writeJSON(filename, data)
+	if err != nil {
+		return err
+	}
+	v.remember(filename, f)
+	return nil
+}
@@ -0,0 +1,65 @@
+package history
+
+import "github.com/rwirdemann/scheduled"
+
+// limit is the maximum number of changes that can be undone.
+const limit = 100
+
+// State is a snapshot of all tasks, contexts and archived tasks.
+type State struct {
+	Tasks    []scheduled.Task
+	Contexts []scheduled.Context
+	Archive  []scheduled.ArchivedTask
+}
+
+type entry struct {
+	label string
+	state State
+}
+
+// History is an undo/redo stack of board states. Each entry stores the state
+// before a change together with a label describing the change.
+type History struct {
+	undo []entry
+	redo []entry
+}
+
+// New creates an empty history.
+func New() *History {
+	return &History{}
+}
+
+// Record adds the state before the change described by label to the history
+// and clears all changes that could be redone.
+func (h *History) Record(label string, before State) {
+	h.undo = append(h.undo, entry{label: label, state: before})
+	if len(h.undo) > limit {
+		h.undo = h.undo[len(h.undo)-limit:]
+	}
+	h.redo = nil
+}
+
+// Undo returns the state before the last change and its label. The current
+// state is kept to redo the change. The last return value is false if there
+// is nothing to undo.
+func (h *History) Undo(current State) (State, string, bool) {
+	if len(h.undo) == 0 {
+		return State{}, "", false
+	}
+	e := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	h.redo = append(h.redo, entry{label: e.label, state: current})
+	return e.state, e.label, true
+}
+
+// Redo returns the state after the last undone change and its label. The last
+// return value is false if there is nothing to redo.
+func (h *History) Redo(current State) (State, string, bool) {
+	if len(h.redo) == 0 {
+		return State{}, "", false
+	}
+	e := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	h.undo = append(h.undo, entry{label: e.label, state: current})
+	return e.state, e.label, true
+}
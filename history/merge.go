@@ -0,0 +1,105 @@
+package history
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+// Merge combines the changes made from base to local with the changes made
+// from base to remote, including the archive. If a task or context was
+// changed on both sides, the local version wins, but a deletion never
+// discards a change made on the other side. The names of all tasks and
+// contexts changed on both sides are returned.
+func Merge(base, local, remote State) (State, []string) {
+	tasks, taskConflicts := merge(base.Tasks, local.Tasks, remote.Tasks, func(t scheduled.Task) string {
+		return t.ID
+	}, equalTasks)
+	contexts, contextConflicts := merge(base.Contexts, local.Contexts, remote.Contexts, func(c scheduled.Context) string {
+		return strconv.Itoa(c.ID)
+	}, func(a, b scheduled.Context) bool {
+		return a == b
+	})
+	archive, _ := merge(base.Archive, local.Archive, remote.Archive, func(a scheduled.ArchivedTask) string {
+		return a.Task.ID
+	}, func(a, b scheduled.ArchivedTask) bool {
+		return a.Completed.Equal(b.Completed) && equalTasks(a.Task, b.Task)
+	})
+
+	var conflicts []string
+	for _, t := range taskConflicts {
+		conflicts = append(conflicts, t.Name)
+	}
+	for _, c := range contextConflicts {
+		conflicts = append(conflicts, c.Name)
+	}
+	return State{Tasks: tasks, Contexts: contexts, Archive: archive}, conflicts
+}
+
+// equalTasks returns true if the tasks are equal. Completion times are equal
+// if they denote the same instant, whatever location they were decoded in.
+func equalTasks(a, b scheduled.Task) bool {
+	if !a.Completed.Equal(b.Completed) {
+		return false
+	}
+	a.Completed, b.Completed = time.Time{}, time.Time{}
+	return reflect.DeepEqual(a, b)
+}
+
+// merge merges the items identified by id and compared with equal. The merged
+// items are in the order of remote followed by items added locally.
+func merge[T any](base, local, remote []T, id func(T) string, equal func(a, b T) bool) ([]T, []T) {
+	index := func(items []T) map[string]T {
+		m := make(map[string]T, len(items))
+		for _, item := range items {
+			m[id(item)] = item
+		}
+		return m
+	}
+	baseItems, localItems, remoteItems := index(base), index(local), index(remote)
+
+	var merged, conflicts []T
+	for _, r := range remote {
+		b, inBase := baseItems[id(r)]
+		l, inLocal := localItems[id(r)]
+		switch {
+		case !inLocal && !inBase:
+			// Added remotely
+			merged = append(merged, r)
+		case !inLocal:
+			// Deleted locally
+			if !equal(b, r) {
+				merged = append(merged, r)
+				conflicts = append(conflicts, r)
+			}
+		case equal(l, b):
+			// Unchanged locally
+			merged = append(merged, r)
+		case equal(r, b) || equal(l, r):
+			// Unchanged remotely or changed the same way on both sides
+			merged = append(merged, l)
+		default:
+			merged = append(merged, l)
+			conflicts = append(conflicts, l)
+		}
+	}
+
+	for _, l := range local {
+		if _, inRemote := remoteItems[id(l)]; inRemote {
+			continue
+		}
+		b, inBase := baseItems[id(l)]
+		switch {
+		case !inBase:
+			// Added locally
+			merged = append(merged, l)
+		case !equal(b, l):
+			// Deleted remotely, but changed locally
+			merged = append(merged, l)
+			conflicts = append(conflicts, l)
+		}
+	}
+	return merged, conflicts
+}
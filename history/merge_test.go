@@ -0,0 +1,89 @@
+package history
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+func TestMerge(t *testing.T) {
+	a := scheduled.Task{ID: "a", Name: "A"}
+	b := scheduled.Task{ID: "b", Name: "B"}
+	renamed := func(t scheduled.Task, name string) scheduled.Task {
+		t.Name = name
+		return t
+	}
+	c := scheduled.Task{ID: "c", Name: "C"}
+
+	tests := []struct {
+		name          string
+		base          []scheduled.Task
+		local         []scheduled.Task
+		remote        []scheduled.Task
+		want          []scheduled.Task
+		wantConflicts []string
+	}{
+		{"unchanged", []scheduled.Task{a}, []scheduled.Task{a}, []scheduled.Task{a}, []scheduled.Task{a}, nil},
+		{"added on both sides", []scheduled.Task{a}, []scheduled.Task{a, b}, []scheduled.Task{a, c}, []scheduled.Task{a, c, b}, nil},
+		{"changed remotely", []scheduled.Task{a}, []scheduled.Task{a}, []scheduled.Task{renamed(a, "X")}, []scheduled.Task{renamed(a, "X")}, nil},
+		{"changed locally", []scheduled.Task{a}, []scheduled.Task{renamed(a, "X")}, []scheduled.Task{a}, []scheduled.Task{renamed(a, "X")}, nil},
+		{"changed on both sides", []scheduled.Task{a}, []scheduled.Task{renamed(a, "X")}, []scheduled.Task{renamed(a, "Y")}, []scheduled.Task{renamed(a, "X")}, []string{"X"}},
+		{"deleted remotely", []scheduled.Task{a, b}, []scheduled.Task{a, b}, []scheduled.Task{a}, []scheduled.Task{a}, nil},
+		{"deleted locally", []scheduled.Task{a, b}, []scheduled.Task{a}, []scheduled.Task{a, b}, []scheduled.Task{a}, nil},
+		{"deleted remotely, changed locally", []scheduled.Task{a, b}, []scheduled.Task{a, renamed(b, "X")}, []scheduled.Task{a}, []scheduled.Task{a, renamed(b, "X")}, []string{"X"}},
+		{"deleted locally, changed remotely", []scheduled.Task{a, b}, []scheduled.Task{a}, []scheduled.Task{a, renamed(b, "Y")}, []scheduled.Task{a, renamed(b, "Y")}, []string{"Y"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, conflicts := Merge(State{Tasks: tt.base}, State{Tasks: tt.local}, State{Tasks: tt.remote})
+			if !reflect.DeepEqual(got.Tasks, tt.want) {
+				t.Errorf("Merge() tasks = %v, want %v", got.Tasks, tt.want)
+			}
+			if !reflect.DeepEqual(conflicts, tt.wantConflicts) {
+				t.Errorf("Merge() conflicts = %v, want %v", conflicts, tt.wantConflicts)
+			}
+		})
+	}
+}
+
+func TestMerge_Contexts(t *testing.T) {
+	base := State{Contexts: []scheduled.Context{scheduled.ContextNone}}
+	local := State{Contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}}}
+	remote := State{Contexts: []scheduled.Context{scheduled.ContextNone, {ID: 3, Name: "home"}}}
+
+	got, _ := Merge(base, local, remote)
+	want := []scheduled.Context{scheduled.ContextNone, {ID: 3, Name: "home"}, {ID: 2, Name: "work"}}
+	if !reflect.DeepEqual(got.Contexts, want) {
+		t.Errorf("Merge() contexts = %v, want %v", got.Contexts, want)
+	}
+}
+
+func TestMerge_Archive(t *testing.T) {
+	a := scheduled.ArchivedTask{Task: scheduled.Task{ID: "a", Name: "A"}}
+	b := scheduled.ArchivedTask{Task: scheduled.Task{ID: "b", Name: "B"}}
+	c := scheduled.ArchivedTask{Task: scheduled.Task{ID: "c", Name: "C"}}
+
+	// b was restored locally, c archived remotely
+	got, _ := Merge(State{Archive: []scheduled.ArchivedTask{a, b}}, State{Archive: []scheduled.ArchivedTask{a}}, State{Archive: []scheduled.ArchivedTask{a, b, c}})
+	want := []scheduled.ArchivedTask{a, c}
+	if !reflect.DeepEqual(got.Archive, want) {
+		t.Errorf("Merge() archive = %v, want %v", got.Archive, want)
+	}
+}
+
+func TestMerge_CompletedInOtherLocation(t *testing.T) {
+	completed := time.Date(2026, time.March, 4, 9, 0, 0, 0, time.FixedZone("CET", 3600))
+	local := scheduled.Task{ID: "a", Name: "A", Done: true, Completed: completed}
+	decoded := local
+	decoded.Completed = completed.UTC()
+	renamed := decoded
+	renamed.Name = "B"
+
+	// The remote change is taken, since local only differs in the location
+	got, conflicts := Merge(State{Tasks: []scheduled.Task{decoded}}, State{Tasks: []scheduled.Task{local}}, State{Tasks: []scheduled.Task{renamed}})
+	if len(conflicts) != 0 || len(got.Tasks) != 1 || got.Tasks[0].Name != "B" {
+		t.Errorf("Merge() = %v, %v, want the remote change without conflicts", got.Tasks, conflicts)
+	}
+}
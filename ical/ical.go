@@ -0,0 +1,117 @@
+// Package ical exports tasks as iCalendar to-dos, see RFC 5545.
+package ical
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/week"
+)
+
+// lineLength is the maximum length of a content line in octets, longer lines
+// are folded.
+const lineLength = 75
+
+var byDay = []string{"", "MO", "TU", "WE", "TH", "FR", "SA", "SU"}
+
+var escaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Format returns the tasks shown in the week as VTODO components of a
+// calendar. Tasks of the days start on their date in the week and are due at
+// its end, which is the start of the next day, tasks of the Inbox have no
+// date. Rolling and recurring tasks repeat with an RRULE
+// starting in the week, and are never completed as a whole. Contexts other
+// than none become categories. stamp is the time the calendar is created.
+func Format(w *week.Week, contexts []scheduled.Context, stamp time.Time) string {
+	names := make(map[int]string)
+	for _, c := range contexts {
+		if c.ID != scheduled.ContextNone.ID {
+			names[c.ID] = c.Name
+		}
+	}
+
+	var b strings.Builder
+	line := func(format string, a ...any) {
+		writeLine(&b, fmt.Sprintf(format, a...))
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//rwirdemann//scheduled//EN")
+	line("CALSCALE:GREGORIAN")
+	for _, i := range w.View() {
+		t := w.Task(i)
+		line("BEGIN:VTODO")
+		line("UID:%s@scheduled", t.ID)
+		line("DTSTAMP:%s", stamp.UTC().Format("20060102T150405Z"))
+		line("SUMMARY:%s", escaper.Replace(t.Name))
+		if t.Desc != "" {
+			line("DESCRIPTION:%s", escaper.Replace(t.Desc))
+		}
+		if name, ok := names[t.Context]; ok {
+			line("CATEGORIES:%s", escaper.Replace(name))
+		}
+		rule := RRule(t)
+		if t.Day != 0 {
+			start := w.DateOf(t.Day)
+			if t.MultiDay() {
+				start = w.DateOf(1)
+			}
+			line("DTSTART;VALUE=DATE:%s", start.Format("20060102"))
+			line("DUE;VALUE=DATE:%s", start.AddDate(0, 0, 1).Format("20060102"))
+		}
+		if rule != "" {
+			line("RRULE:%s", rule)
+		}
+		if t.Done && rule == "" {
+			line("STATUS:COMPLETED")
+		} else {
+			line("STATUS:NEEDS-ACTION")
+		}
+		line("END:VTODO")
+	}
+	line("END:VCALENDAR")
+	return b.String()
+}
+
+// RRule returns the recurrence rule of the task, or "" for tasks of the Inbox
+// and pinned tasks, which occur once.
+func RRule(t scheduled.Task) string {
+	if t.Day == 0 || t.Pinned() {
+		return ""
+	}
+	r := t.Recurrence
+	switch {
+	case r == nil:
+		return "FREQ=WEEKLY;BYDAY=" + byDay[t.Day]
+	case r.Freq == scheduled.FreqWeekdays:
+		return "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"
+	case r.Freq == scheduled.FreqMonthly:
+		return fmt.Sprintf("FREQ=MONTHLY;BYDAY=%d%s", r.Nth, byDay[t.Day])
+	case r.Interval > 1:
+		return fmt.Sprintf("FREQ=WEEKLY;INTERVAL=%d;BYDAY=%s", r.Interval, byDay[t.Day])
+	}
+	return "FREQ=WEEKLY;BYDAY=" + byDay[t.Day]
+}
+
+// writeLine writes the content line terminated by CRLF and folds it after
+// lineLength octets without splitting characters.
+func writeLine(b *strings.Builder, s string) {
+	limit := lineLength
+	for len(s) > limit {
+		n := limit
+		for n > 0 && !utf8.RuneStart(s[n]) {
+			n--
+		}
+		b.WriteString(s[:n])
+		b.WriteString("\r\n ")
+		s = s[n:]
+
+		// The leading space of continuation lines counts as well
+		limit = lineLength - 1
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,128 @@
+package ical
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/week"
+)
+
+// week24 is a day in week 24 of 2026, which starts on Monday, June 8, the
+// second Monday of the month.
+var week24 = time.Date(2026, time.June, 10, 12, 0, 0, 0, time.Local)
+
+func TestFormat(t *testing.T) {
+	stamp := time.Date(2026, time.June, 10, 6, 15, 0, 0, time.UTC)
+	w := week.New([]scheduled.Task{
+		{ID: "standup", Name: "Standup", Day: 3, Context: 2, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}},
+		{ID: "review", Name: "Sprint review", Day: 4, DoneWeeks: []string{"2026-W24"}, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekly, Interval: 2, Start: "2026-05-28"}},
+		{ID: "rent", Name: "Pay rent", Day: 1, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqMonthly, Nth: 2}},
+		{ID: "dentist", Name: "Dentist", Day: 5, Date: "2026-06-12", Done: true, Desc: "Bring card; ask about x-ray"},
+	}, week24)
+	contexts := []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "team, ops"}}
+
+	want := "BEGIN:VCALENDAR\r\n" +
+		"VERSION:2.0\r\n" +
+		"PRODID:-//rwirdemann//scheduled//EN\r\n" +
+		"CALSCALE:GREGORIAN\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:rent@scheduled\r\n" +
+		"DTSTAMP:20260610T061500Z\r\n" +
+		"SUMMARY:Pay rent\r\n" +
+		"DTSTART;VALUE=DATE:20260608\r\n" +
+		"DUE;VALUE=DATE:20260609\r\n" +
+		"RRULE:FREQ=MONTHLY;BYDAY=2MO\r\n" +
+		"STATUS:NEEDS-ACTION\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:standup@scheduled\r\n" +
+		"DTSTAMP:20260610T061500Z\r\n" +
+		"SUMMARY:Standup\r\n" +
+		"CATEGORIES:team\\, ops\r\n" +
+		"DTSTART;VALUE=DATE:20260608\r\n" +
+		"DUE;VALUE=DATE:20260609\r\n" +
+		"RRULE:FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR\r\n" +
+		"STATUS:NEEDS-ACTION\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:review@scheduled\r\n" +
+		"DTSTAMP:20260610T061500Z\r\n" +
+		"SUMMARY:Sprint review\r\n" +
+		"DTSTART;VALUE=DATE:20260611\r\n" +
+		"DUE;VALUE=DATE:20260612\r\n" +
+		"RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=TH\r\n" +
+		"STATUS:NEEDS-ACTION\r\n" +
+		"END:VTODO\r\n" +
+		"BEGIN:VTODO\r\n" +
+		"UID:dentist@scheduled\r\n" +
+		"DTSTAMP:20260610T061500Z\r\n" +
+		"SUMMARY:Dentist\r\n" +
+		"DESCRIPTION:Bring card\\; ask about x-ray\r\n" +
+		"DTSTART;VALUE=DATE:20260612\r\n" +
+		"DUE;VALUE=DATE:20260613\r\n" +
+		"STATUS:COMPLETED\r\n" +
+		"END:VTODO\r\n" +
+		"END:VCALENDAR\r\n"
+	if got := Format(w, contexts, stamp); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestFormat_DueAfterStart(t *testing.T) {
+	// The last day of March is followed by the first of April
+	w := week.New([]scheduled.Task{{ID: "1", Name: "Close the quarter", Day: 2, Date: "2026-03-31"}}, time.Date(2026, time.March, 31, 12, 0, 0, 0, time.Local))
+
+	got := Format(w, nil, week24)
+	for _, line := range []string{"DTSTART;VALUE=DATE:20260331\r\n", "DUE;VALUE=DATE:20260401\r\n"} {
+		if !strings.Contains(got, line) {
+			t.Errorf("Format() = %q, want it to contain %q", got, line)
+		}
+	}
+}
+
+func TestRRule(t *testing.T) {
+	tests := []struct {
+		name string
+		task scheduled.Task
+		want string
+	}{
+		{"inbox", scheduled.Task{}, ""},
+		{"pinned", scheduled.Task{Day: 5, Date: "2026-03-06"}, ""},
+		{"rolling", scheduled.Task{Day: 1}, "FREQ=WEEKLY;BYDAY=MO"},
+		{"every 2 weeks", scheduled.Task{Day: 7, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekly, Interval: 2}}, "FREQ=WEEKLY;INTERVAL=2;BYDAY=SU"},
+		{"second in month", scheduled.Task{Day: 2, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqMonthly, Nth: 2}}, "FREQ=MONTHLY;BYDAY=2TU"},
+		{"last in month", scheduled.Task{Day: 5, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqMonthly, Nth: -1}}, "FREQ=MONTHLY;BYDAY=-1FR"},
+		{"weekdays", scheduled.Task{Day: 1, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}}, "FREQ=WEEKLY;BYDAY=MO,TU,WE,TH,FR"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RRule(tt.task); got != tt.want {
+				t.Errorf("RRule() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteLine_Folds(t *testing.T) {
+	var b strings.Builder
+	writeLine(&b, "SUMMARY:"+strings.Repeat("ä", 80))
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\r\n"), "\r\n")
+	if len(lines) != 3 {
+		t.Fatalf("writeLine() = %q, want 3 lines", b.String())
+	}
+	for i, line := range lines {
+		if len(line) > lineLength {
+			t.Errorf("line %d has %d octets, want at most %d", i, len(line), lineLength)
+		}
+		if i > 0 && !strings.HasPrefix(line, " ") {
+			t.Errorf("line %d = %q, want a leading space", i, line)
+		}
+	}
+	if got := strings.ReplaceAll(b.String(), "\r\n ", ""); got != "SUMMARY:"+strings.Repeat("ä", 80)+"\r\n" {
+		t.Errorf("unfolded = %q", got)
+	}
+}
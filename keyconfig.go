@@ -0,0 +1,196 @@
+package scheduled
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// Presets are the built-in key maps by name.
+var Presets = map[string]KeyMap{
+	"default": Keys,
+	"vim":     VimKeys,
+}
+
+// KeyConfig selects a preset and overrides its key bindings by the names of
+// the fields of KeyMap and ContextViewKeyMap. An empty list of keys disables a
+// binding.
+//
+//	{
+//	  "preset": "vim",
+//	  "board": {"CopyTasks": ["Y"], "Back": ["d d", "delete"]},
+//	  "contexts": {"DeleteContext": ["d"]}
+//	}
+type KeyConfig struct {
+	Preset   string              `json:"preset"`
+	Board    map[string][]string `json:"board"`
+	Contexts map[string][]string `json:"contexts"`
+}
+
+// LoadKeyConfig reads the key config from the given file. A missing file
+// results in an empty config.
+func LoadKeyConfig(filename string) (KeyConfig, error) {
+	var c KeyConfig
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, fmt.Errorf("invalid key config %s: %w", filename, err)
+	}
+	return c, nil
+}
+
+// Keys returns the key maps of the preset with the bindings of the config.
+// It fails for unknown presets or binding names and for keys bound to more
+// than one action.
+func (c KeyConfig) Keys() (KeyMap, ContextViewKeyMap, error) {
+	name := c.Preset
+	if name == "" {
+		name = "default"
+	}
+	keys, ok := Presets[name]
+	if !ok {
+		return keys, ContextViewKeys, fmt.Errorf("unknown preset '%s'", c.Preset)
+	}
+	return c.Apply(keys, ContextViewKeys)
+}
+
+// Apply returns the given key maps with the bindings of the config. It fails
+// for unknown binding names, for keys bound to more than one action and for
+// sequences of keys, which only the board of the vim preset understands.
+func (c KeyConfig) Apply(keys KeyMap, contextKeys ContextViewKeyMap) (KeyMap, ContextViewKeyMap, error) {
+	if err := override(&keys, c.Board, c.Preset == "vim"); err != nil {
+		return keys, contextKeys, fmt.Errorf("board: %w", err)
+	}
+	if err := override(&contextKeys, c.Contexts, false); err != nil {
+		return keys, contextKeys, fmt.Errorf("contexts: %w", err)
+	}
+	if err := ValidateKeys(keys, contextKeys); err != nil {
+		return keys, contextKeys, err
+	}
+	return keys, contextKeys, nil
+}
+
+// ValidateKeys returns an error if a key is bound to more than one action of
+// the board or of the context view. Quit is available in every view and
+// therefore must not be used by the context view either.
+func ValidateKeys(keys KeyMap, contextKeys ContextViewKeyMap) error {
+	if err := conflicts(bindings(&keys)); err != nil {
+		return fmt.Errorf("board: %w", err)
+	}
+	contexts := bindings(&contextKeys)
+	contexts = append(contexts, namedBinding{"Quit", &keys.Quit})
+	if err := conflicts(contexts); err != nil {
+		return fmt.Errorf("contexts: %w", err)
+	}
+	return nil
+}
+
+// namedBinding is a binding of a key map together with its field name.
+type namedBinding struct {
+	name    string
+	binding *key.Binding
+}
+
+// bindings returns the bindings of the key map k points to in field order.
+func bindings(k any) []namedBinding {
+	v := reflect.ValueOf(k).Elem()
+	var result []namedBinding
+	for i := range v.NumField() {
+		if b, ok := v.Field(i).Addr().Interface().(*key.Binding); ok {
+			result = append(result, namedBinding{v.Type().Field(i).Name, b})
+		}
+	}
+	return result
+}
+
+// override replaces the keys of the bindings named in config. Sequences of
+// keys are refused unless allowed.
+func override(k any, config map[string][]string, sequences bool) error {
+	all := bindings(k)
+	for name, keys := range config {
+		i := findBinding(all, name)
+		if i < 0 {
+			return fmt.Errorf("unknown key binding '%s'", name)
+		}
+		for _, k := range keys {
+			if len(strings.Fields(k)) > 1 && !sequences {
+				return fmt.Errorf("key '%s' of %s is a sequence, which needs the vim preset", keyHelp([]string{k}), name)
+			}
+		}
+		b := all[i].binding
+		desc := b.Help().Desc
+		*b = key.NewBinding(key.WithKeys(keys...), key.WithHelp(keyHelp(keys), desc))
+		if len(keys) == 0 {
+			b.SetEnabled(false)
+		}
+	}
+	return nil
+}
+
+func findBinding(bindings []namedBinding, name string) int {
+	for i, b := range bindings {
+		if strings.EqualFold(b.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// conflicts returns an error for the first key bound to more than one of the
+// enabled bindings. A key also conflicts with the sequences it starts.
+func conflicts(bindings []namedBinding) error {
+	owners := make(map[string]string)
+	for _, b := range bindings {
+		if !b.binding.Enabled() {
+			continue
+		}
+		for _, k := range b.binding.Keys() {
+			if owner, ok := owners[k]; ok && owner != b.name {
+				return fmt.Errorf("key '%s' is bound to %s and %s", keyHelp([]string{k}), owner, b.name)
+			}
+			owners[k] = b.name
+		}
+	}
+	for k, owner := range owners {
+		keys := strings.Fields(k)
+		for i := 1; i < len(keys); i++ {
+			prefix := strings.Join(keys[:i], " ")
+			if other, ok := owners[prefix]; ok {
+				return fmt.Errorf("key '%s' of %s starts '%s' of %s", keyHelp([]string{prefix}), other, keyHelp([]string{k}), owner)
+			}
+		}
+	}
+	return nil
+}
+
+var keySymbols = strings.NewReplacer(
+	" ", "space",
+	"left", "←",
+	"right", "→",
+	"up", "↑",
+	"down", "↓",
+)
+
+// keyHelp returns the keys as shown in the help. Sequences are shown without
+// spaces, like in vim.
+func keyHelp(keys []string) string {
+	help := make([]string, len(keys))
+	for i, k := range keys {
+		if sequence := strings.Fields(k); len(sequence) > 1 {
+			help[i] = strings.Join(sequence, "")
+		} else {
+			help[i] = keySymbols.Replace(k)
+		}
+	}
+	return strings.Join(help, "/")
+}
@@ -0,0 +1,130 @@
+package scheduled
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestKeyConfig_Apply(t *testing.T) {
+	c := KeyConfig{
+		Board:    map[string][]string{"CopyTasks": {"y"}, "back": {"x", "delete"}, "Pin": {}},
+		Contexts: map[string][]string{"DeleteContext": {"d"}},
+	}
+
+	keys, contextKeys, err := c.Keys()
+	if err != nil {
+		t.Fatalf("Keys() error = %v", err)
+	}
+
+	y := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}}
+	if !key.Matches(y, keys.CopyTasks) {
+		t.Error("y should copy tasks")
+	}
+	if got := keys.Back.Help(); got.Key != "x/delete" || got.Desc != "delete task" {
+		t.Errorf("Back help = %+v", got)
+	}
+	if keys.Pin.Enabled() {
+		t.Error("Pin should be disabled")
+	}
+	if contextKeys.DeleteContext.Help().Key != "d" {
+		t.Errorf("DeleteContext help = %+v", contextKeys.DeleteContext.Help())
+	}
+
+	// The defaults are not changed
+	if Keys.CopyTasks.Keys()[0] != "k" {
+		t.Errorf("default CopyTasks keys = %v", Keys.CopyTasks.Keys())
+	}
+}
+
+func TestKeyConfig_ApplyErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config KeyConfig
+		want   string
+	}{
+		{
+			name:   "unknown binding",
+			config: KeyConfig{Board: map[string][]string{"Fly": {"f"}}},
+			want:   "unknown key binding 'Fly'",
+		},
+		{
+			name:   "conflict on the board",
+			config: KeyConfig{Board: map[string][]string{"CopyTasks": {"n"}}},
+			want:   "key 'n' is bound to New and CopyTasks",
+		},
+		{
+			name:   "conflict in the context view",
+			config: KeyConfig{Contexts: map[string][]string{"DeleteContext": {"n"}}},
+			want:   "key 'n' is bound to NewContext and DeleteContext",
+		},
+		{
+			name:   "key starts a sequence",
+			config: KeyConfig{Preset: "vim", Board: map[string][]string{"Details": {"d"}}},
+			want:   "key 'd' of Details starts 'dd' of Back",
+		},
+		{
+			name:   "sequence without vim",
+			config: KeyConfig{Board: map[string][]string{"Back": {"d d"}}},
+			want:   "key 'dd' of Back is a sequence, which needs the vim preset",
+		},
+		{
+			name:   "sequence in the context view",
+			config: KeyConfig{Preset: "vim", Contexts: map[string][]string{"DeleteContext": {"d d"}}},
+			want:   "key 'dd' of DeleteContext is a sequence",
+		},
+		{
+			name:   "unknown preset",
+			config: KeyConfig{Preset: "emacs"},
+			want:   "unknown preset 'emacs'",
+		},
+		{
+			name:   "context view uses quit",
+			config: KeyConfig{Contexts: map[string][]string{"CloseView": {"q"}}},
+			want:   "key 'q' is bound to CloseView and Quit",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := tt.config.Keys()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Keys() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeyConfig_Preset(t *testing.T) {
+	c := KeyConfig{Preset: "vim", Board: map[string][]string{"CopyTasks": {"Y"}}}
+
+	keys, _, err := c.Keys()
+	if err != nil {
+		t.Fatalf("Keys() error = %v", err)
+	}
+	if keys.ShiftRight.Help().Key != "L" || keys.CopyTasks.Help().Key != "Y" {
+		t.Errorf("keys = %+v, want vim keys with Y to copy", keys)
+	}
+}
+
+func TestLoadKeyConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	c, err := LoadKeyConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || len(c.Board) != 0 {
+		t.Errorf("LoadKeyConfig() = %+v, %v, want empty config", c, err)
+	}
+
+	filename := filepath.Join(dir, "keys.json")
+	if err := os.WriteFile(filename, []byte(`{"board": {"CopyTasks": ["y"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	c, err = LoadKeyConfig(filename)
+	if err != nil || c.Board["CopyTasks"][0] != "y" {
+		t.Errorf("LoadKeyConfig() = %+v, %v", c, err)
+	}
+}
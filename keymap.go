@@ -3,26 +3,39 @@ package scheduled
 import "github.com/charmbracelet/bubbles/key"
 
 type KeyMap struct {
-	NextDay     key.Binding
-	PrevDay     key.Binding
-	ShiftRight  key.Binding
-	ShiftLeft   key.Binding
-	ShiftUp     key.Binding
-	ShiftDown   key.Binding
-	Right       key.Binding
-	Left        key.Binding
-	New         key.Binding
-	Esc         key.Binding
-	Back        key.Binding
-	Space       key.Binding
-	Help        key.Binding
-	Enter       key.Binding
-	Quit        key.Binding
-	Num         key.Binding
-	MoveToToday key.Binding
-	MoveToInbox key.Binding
-	Contexts    key.Binding
-	CopyTasks   key.Binding
+	NextDay      key.Binding
+	PrevDay      key.Binding
+	ShiftRight   key.Binding
+	ShiftLeft    key.Binding
+	ShiftUp      key.Binding
+	ShiftDown    key.Binding
+	Right        key.Binding
+	Left         key.Binding
+	New          key.Binding
+	Esc          key.Binding
+	Back         key.Binding
+	Space        key.Binding
+	Help         key.Binding
+	Enter        key.Binding
+	Quit         key.Binding
+	Num          key.Binding
+	MoveToToday  key.Binding
+	MoveToInbox  key.Binding
+	Contexts     key.Binding
+	CopyTasks    key.Binding
+	CopyWeek     key.Binding
+	Pin          key.Binding
+	Details      key.Binding
+	Descriptions key.Binding
+	Undo         key.Binding
+	Redo         key.Binding
+	Top          key.Binding
+	Bottom       key.Binding
+	Search       key.Binding
+	Palette      key.Binding
+	Mark         key.Binding
+	Archive      key.Binding
+	Report       key.Binding
 }
 
 // ShortHelp returns keybindings to be shown in the mini help view. It's part
@@ -120,6 +133,58 @@ var Keys = KeyMap{
 		key.WithKeys("k"),
 		key.WithHelp("k", "copy tasks"),
 	),
+	CopyWeek: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy week"),
+	),
+	Pin: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pin / unpin task"),
+	),
+	Details: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "toggle details"),
+	),
+	Descriptions: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "toggle notes in lists"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("home"),
+		key.WithHelp("home", "first task"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("end"),
+		key.WithHelp("end", "last task"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search tasks"),
+	),
+	Palette: key.NewBinding(
+		key.WithKeys(":", "ctrl+p"),
+		key.WithHelp(":", "command palette"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "mark / unmark task"),
+	),
+	Archive: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "show archive"),
+	),
+	Report: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "copy week report"),
+	),
 }
 
 type ContextViewKeyMap struct {
@@ -143,7 +208,10 @@ func (k KeyMap) FullHelp() [][]key.Binding {
 		{k.NextDay, k.PrevDay, k.Right, k.Left},
 		{k.ShiftRight, k.ShiftLeft, k.ShiftDown, k.ShiftUp},
 		{k.Num, k.MoveToToday, k.MoveToInbox, k.Esc},
-		{k.Help, k.Contexts, k.CopyTasks, k.Quit},
+		{k.Help, k.Contexts, k.CopyTasks, k.CopyWeek, k.Quit},
+		{k.Pin, k.Details, k.Descriptions, k.Search},
+		{k.Undo, k.Redo, k.Top, k.Bottom},
+		{k.Palette, k.Mark, k.Archive, k.Report},
 	}
 }
 
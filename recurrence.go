@@ -0,0 +1,130 @@
+package scheduled
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FreqWeekly   = "weekly"
+	FreqMonthly  = "monthly"
+	FreqWeekdays = "weekdays"
+)
+
+// Recurrence describes how a rolling task repeats. Tasks without a recurrence
+// repeat on the same weekday every week.
+type Recurrence struct {
+	Freq string `json:"freq"`
+
+	// Interval is the number of weeks between two occurrences of a weekly
+	// recurrence, counted from the week of Start.
+	Interval int    `json:"interval,omitempty"`
+	Start    string `json:"start,omitempty"`
+
+	// Nth is the occurrence of the weekday within the month for monthly
+	// recurrences, 1 for the first and -1 for the last one.
+	Nth int `json:"nth,omitempty"`
+}
+
+// RecurrenceOptions lists the rules offered in the task form together with the
+// labels shown to the user.
+var RecurrenceOptions = []struct {
+	Label string
+	Rule  string
+}{
+	{"Every week", ""},
+	{"Every 2 weeks", "weekly:2"},
+	{"Every 3 weeks", "weekly:3"},
+	{"Every 4 weeks", "weekly:4"},
+	{"First in month", "monthly:1"},
+	{"Second in month", "monthly:2"},
+	{"Third in month", "monthly:3"},
+	{"Fourth in month", "monthly:4"},
+	{"Last in month", "monthly:-1"},
+	{"Every weekday", "weekdays"},
+}
+
+// ParseRecurrence parses a rule like "weekly:2", "monthly:-1" or "weekdays".
+// An empty rule returns nil.
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	if rule == "" {
+		return nil, nil
+	}
+	freq, arg, _ := strings.Cut(rule, ":")
+	switch freq {
+	case FreqWeekdays:
+		return &Recurrence{Freq: FreqWeekdays}, nil
+	case FreqWeekly:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid interval in rule '%s'", rule)
+		}
+		if n == 1 {
+			return nil, nil
+		}
+		return &Recurrence{Freq: FreqWeekly, Interval: n}, nil
+	case FreqMonthly:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < -1 || n == 0 || n > 5 {
+			return nil, fmt.Errorf("invalid week of month in rule '%s'", rule)
+		}
+		return &Recurrence{Freq: FreqMonthly, Nth: n}, nil
+	}
+	return nil, fmt.Errorf("unknown rule '%s'", rule)
+}
+
+// String returns the rule of the recurrence in the format understood by
+// ParseRecurrence.
+func (r *Recurrence) String() string {
+	if r == nil {
+		return ""
+	}
+	switch r.Freq {
+	case FreqWeekly:
+		return fmt.Sprintf("%s:%d", FreqWeekly, r.Interval)
+	case FreqMonthly:
+		return fmt.Sprintf("%s:%d", FreqMonthly, r.Nth)
+	}
+	return r.Freq
+}
+
+// OccursOn returns true if a task on the given weekday (1 for Monday up to 7
+// for Sunday) is due on the given day.
+func (r *Recurrence) OccursOn(weekday int, day time.Time) bool {
+	switch r.Freq {
+	case FreqWeekdays:
+		return Weekday(day) <= 5
+	case FreqMonthly:
+		if Weekday(day) != weekday {
+			return false
+		}
+		if r.Nth < 0 {
+			return day.AddDate(0, 0, 7).Month() != day.Month()
+		}
+		return (day.Day()-1)/7+1 == r.Nth
+	default:
+		if Weekday(day) != weekday {
+			return false
+		}
+		start, err := time.ParseInLocation(DateFormat, r.Start, time.Local)
+		if r.Interval <= 1 || err != nil {
+			return true
+		}
+		weeks := daysBetween(mondayOf(start), mondayOf(day)) / 7
+		return ((weeks%r.Interval)+r.Interval)%r.Interval == 0
+	}
+}
+
+func mondayOf(d time.Time) time.Time {
+	return d.AddDate(0, 0, 1-Weekday(d))
+}
+
+// daysBetween returns the number of calendar days from a to b, ignoring
+// daylight saving time shifts.
+func daysBetween(a, b time.Time) int {
+	ua := time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	ub := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(ub.Sub(ua).Hours() / 24)
+}
@@ -0,0 +1,178 @@
+// Package report renders the tasks of one or more weeks with text/template,
+// by default as Markdown.
+package report
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/template"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+)
+
+// DefaultTemplate is the name of the template used if no other is given. A
+// file of that name in the template directory replaces the built-in one.
+const DefaultTemplate = "default"
+
+//go:embed default.tmpl
+var defaultTemplate string
+
+var dayNames = []string{"", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday", "Sunday"}
+
+// Report holds the weeks of a report together with the number of their tasks
+// and of the tasks done.
+type Report struct {
+	Weeks []Week
+	Done  int
+	Total int
+}
+
+// Week holds the days of a calendar week from Monday to Sunday.
+type Week struct {
+	Year   int
+	Week   int
+	Monday time.Time
+	Days   []Day
+	Done   int
+	Total  int
+}
+
+// Day holds the tasks scheduled for a date grouped by context.
+type Day struct {
+	Name   string
+	Date   time.Time
+	Groups []Group
+	Done   int
+	Total  int
+}
+
+// Group holds the tasks of a day in one context. Done is set for the day.
+type Group struct {
+	Context string
+	Tasks   []scheduled.Task
+}
+
+// New creates a report of the given number of weeks, starting with the week
+// of year and week. Tasks in the Inbox are not part of the report.
+func New(tasks []scheduled.Task, contexts []scheduled.Context, year, week, weeks int) Report {
+	var r Report
+	monday := date.GetMondayOfWeek(year, week)
+	for range weeks {
+		w := newWeek(tasks, contexts, monday)
+		r.Weeks = append(r.Weeks, w)
+		r.Done += w.Done
+		r.Total += w.Total
+		monday = monday.AddDate(0, 0, 7)
+	}
+	return r
+}
+
+func newWeek(tasks []scheduled.Task, contexts []scheduled.Context, monday time.Time) Week {
+	year, week := monday.ISOWeek()
+	w := Week{Year: year, Week: week, Monday: monday}
+	for day := 1; day <= 7; day++ {
+		d := newDay(tasks, contexts, day, monday.AddDate(0, 0, day-1))
+		w.Days = append(w.Days, d)
+		w.Done += d.Done
+		w.Total += d.Total
+	}
+	return w
+}
+
+func newDay(tasks []scheduled.Task, contexts []scheduled.Context, day int, date time.Time) Day {
+	var scheduledTasks []scheduled.Task
+	for _, t := range tasks {
+		if (t.Day != 0 || t.Pinned()) && t.OccursOn(date) {
+			t.Done = t.DoneOn(date)
+			scheduledTasks = append(scheduledTasks, t)
+		}
+	}
+	sort.SliceStable(scheduledTasks, func(a, b int) bool {
+		return scheduledTasks[a].Pos < scheduledTasks[b].Pos
+	})
+
+	d := Day{Name: dayNames[day], Date: date}
+	byContext := make(map[int][]scheduled.Task)
+	for _, t := range scheduledTasks {
+		byContext[t.Context] = append(byContext[t.Context], t)
+		d.Total++
+		if t.Done {
+			d.Done++
+		}
+	}
+	contextIDs := make([]int, 0, len(byContext))
+	for id := range byContext {
+		contextIDs = append(contextIDs, id)
+	}
+	sort.Ints(contextIDs)
+	for _, id := range contextIDs {
+		d.Groups = append(d.Groups, Group{Context: contextName(contexts, id), Tasks: byContext[id]})
+	}
+	return d
+}
+
+func contextName(contexts []scheduled.Context, id int) string {
+	for _, c := range contexts {
+		if c.ID == id {
+			return c.Name
+		}
+	}
+	return "Unknown"
+}
+
+// funcs are the functions available in templates besides the built-in ones.
+var funcs = template.FuncMap{
+	"checkbox": func(done bool) string {
+		if done {
+			return "[x]"
+		}
+		return "[ ]"
+	},
+	"percent": func(done, total int) int {
+		if total == 0 {
+			return 0
+		}
+		return done * 100 / total
+	},
+}
+
+// Load returns the template of the given name from dir, which contains
+// templates as files named after them with the extension .tmpl. The default
+// template falls back to the built-in Markdown template if dir doesn't
+// contain it.
+func Load(dir, name string) (*template.Template, error) {
+	if name == "" {
+		name = DefaultTemplate
+	}
+	filename := filepath.Join(dir, name+".tmpl")
+	text, err := os.ReadFile(filename)
+	if errors.Is(err, os.ErrNotExist) && name == DefaultTemplate {
+		return Parse(name, defaultTemplate)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", name, err)
+	}
+	return Parse(name, string(text))
+}
+
+// Parse parses the text of a template, which may use the functions checkbox
+// and percent.
+func Parse(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(funcs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("template '%s': %w", name, err)
+	}
+	return t, nil
+}
+
+// Render writes the report executed with the template to out.
+func Render(out io.Writer, r Report, t *template.Template) error {
+	return t.Execute(out, r)
+}
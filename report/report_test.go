@@ -0,0 +1,125 @@
+package report
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+var contexts = []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}}
+
+var tasks = []scheduled.Task{
+	{ID: "1", Name: "Inbox task", Context: 1},
+	{ID: "2", Name: "Write report", Day: 2, Context: 2, Pos: 1, DoneWeeks: []string{"2026-W10"}},
+	{ID: "3", Name: "Buy milk", Day: 2, Context: 1, Pos: 0},
+	{ID: "4", Name: "Standup", Day: 1, Context: 2, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}, DoneWeeks: []string{"2026-03-02"}},
+	{ID: "5", Name: "Tax office", Day: 5, Date: "2026-03-13", Context: 1, Done: true},
+}
+
+func TestNew(t *testing.T) {
+	r := New(tasks, contexts, 2026, 10, 2)
+
+	if len(r.Weeks) != 2 || r.Weeks[0].Week != 10 || r.Weeks[1].Week != 11 {
+		t.Fatalf("weeks = %+v, want weeks 10 and 11", r.Weeks)
+	}
+	if w := r.Weeks[0]; w.Done != 2 || w.Total != 7 {
+		t.Errorf("week 10 = %d of %d done, want 2 of 7", w.Done, w.Total)
+	}
+	if w := r.Weeks[1]; w.Done != 1 || w.Total != 8 {
+		t.Errorf("week 11 = %d of %d done, want 1 of 8", w.Done, w.Total)
+	}
+	if r.Done != 3 || r.Total != 15 {
+		t.Errorf("report = %d of %d done, want 3 of 15", r.Done, r.Total)
+	}
+
+	tuesday := r.Weeks[0].Days[1]
+	if len(tuesday.Groups) != 2 || tuesday.Groups[0].Context != "none" || tuesday.Groups[1].Context != "work" {
+		t.Fatalf("groups = %+v, want none and work", tuesday.Groups)
+	}
+	if work := tuesday.Groups[1].Tasks; len(work) != 2 || work[0].Name != "Standup" || work[0].Done || work[1].Name != "Write report" || !work[1].Done {
+		t.Errorf("work = %+v, want Standup open and Write report done", work)
+	}
+}
+
+func TestRender_Default(t *testing.T) {
+	tmpl, err := Load(t.TempDir(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := Render(&b, New(tasks[:3], contexts, 2026, 10, 1), tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `# Week 10, 2026
+
+1 of 2 tasks done
+
+## Monday, March 2 (0/0)
+
+No tasks
+
+## Tuesday, March 3 (1/2)
+
+### none
+
+- [ ] Buy milk
+
+### work
+
+- [x] Write report
+
+## Wednesday, March 4 (0/0)
+
+No tasks
+
+## Thursday, March 5 (0/0)
+
+No tasks
+
+## Friday, March 6 (0/0)
+
+No tasks
+
+## Saturday, March 7 (0/0)
+
+No tasks
+
+## Sunday, March 8 (0/0)
+
+No tasks
+`
+	if got := b.String(); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestLoad_UserTemplate(t *testing.T) {
+	dir := t.TempDir()
+	text := "{{range .Weeks}}W{{.Week}}: {{percent .Done .Total}}%{{end}}"
+	if err := os.WriteFile(filepath.Join(dir, "short.tmpl"), []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := Load(dir, "short")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b strings.Builder
+	if err := Render(&b, New(tasks, contexts, 2026, 10, 1), tmpl); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.String(); got != "W10: 28%" {
+		t.Errorf("Render() = %q, want W10: 28%%", got)
+	}
+
+	if _, err := Load(dir, "missing"); err == nil {
+		t.Error("Load() should fail for missing templates")
+	}
+	if _, err := Parse("broken", "{{.Weeks"); err == nil {
+		t.Error("Parse() should fail for invalid templates")
+	}
+}
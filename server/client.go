@@ -0,0 +1,183 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rwirdemann/scheduled"
+)
+
+// reconnectInterval is the time the client waits before it reconnects to the
+// event stream of the server.
+const reconnectInterval = 2 * time.Second
+
+// Client is a repository that loads and saves tasks, contexts and the archive
+// through a server started with "scheduled serve". Saving fails with
+// scheduled.ErrModified if another client saved in the meantime.
+type Client struct {
+	url  string
+	id   string
+	http *http.Client
+
+	// versions holds the ETag of the last load or save by path
+	versionsMu sync.Mutex
+	versions   map[string]string
+
+	once    sync.Once
+	changes chan struct{}
+}
+
+// NewClient creates a client for the server at the given URL, e.g.
+// http://localhost:7070.
+func NewClient(url string) *Client {
+	return &Client{
+		url:      strings.TrimSuffix(url, "/"),
+		id:       uuid.NewString(),
+		http:     &http.Client{Timeout: 10 * time.Second},
+		versions: make(map[string]string),
+		changes:  make(chan struct{}, 1),
+	}
+}
+
+func (c *Client) LoadTasks() ([]scheduled.Task, error) {
+	var tasks []scheduled.Task
+	err := c.do(http.MethodGet, "/api/store/tasks", nil, &tasks)
+	return tasks, err
+}
+
+func (c *Client) SaveTasks(tasks []scheduled.Task) error {
+	return c.do(http.MethodPut, "/api/store/tasks", tasks, nil)
+}
+
+func (c *Client) LoadContexts() ([]scheduled.Context, error) {
+	var contexts []scheduled.Context
+	err := c.do(http.MethodGet, "/api/store/contexts", nil, &contexts)
+	return contexts, err
+}
+
+func (c *Client) SaveContexts(contexts []scheduled.Context) error {
+	return c.do(http.MethodPut, "/api/store/contexts", contexts, nil)
+}
+
+func (c *Client) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	var archive []scheduled.ArchivedTask
+	err := c.do(http.MethodGet, "/api/store/archive", nil, &archive)
+	return archive, err
+}
+
+func (c *Client) SaveArchive(archive []scheduled.ArchivedTask) error {
+	return c.do(http.MethodPut, "/api/store/archive", archive, nil)
+}
+
+// Changes returns a channel that receives a value whenever tasks or contexts
+// were changed by another client of the server.
+func (c *Client) Changes() <-chan struct{} {
+	c.once.Do(func() {
+		go c.watch()
+	})
+	return c.changes
+}
+
+// watch reads the event stream of the server and reconnects if the
+// connection is lost.
+func (c *Client) watch() {
+	for {
+		_ = c.readEvents()
+		time.Sleep(reconnectInterval)
+	}
+}
+
+func (c *Client) readEvents() error {
+	resp, err := http.Get(c.url + "/api/events")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil || e.Origin == c.id {
+			continue
+		}
+
+		// A pending notification already covers this change
+		select {
+		case c.changes <- struct{}{}:
+		default:
+		}
+	}
+	return scanner.Err()
+}
+
+func (c *Client) do(method, path string, body any, result any) error {
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, c.url+path, &buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ClientHeader, c.id)
+	if method == http.MethodPut {
+		if v := c.version(path); v != "" {
+			req.Header.Set("If-Match", v)
+		}
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode == http.StatusConflict {
+		return scheduled.ErrModified
+	}
+	if resp.StatusCode >= 300 {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return fmt.Errorf("server responded with %s: %s", resp.Status, e.Error)
+		}
+		return fmt.Errorf("server responded with %s", resp.Status)
+	}
+	if v := resp.Header.Get("ETag"); v != "" {
+		c.setVersion(path, v)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (c *Client) version(path string) string {
+	c.versionsMu.Lock()
+	defer c.versionsMu.Unlock()
+	return c.versions[path]
+}
+
+func (c *Client) setVersion(path, v string) {
+	c.versionsMu.Lock()
+	defer c.versionsMu.Unlock()
+	c.versions[path] = v
+}
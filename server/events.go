@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	EventTasks    = "tasks"
+	EventContexts = "contexts"
+	EventArchive  = "archive"
+)
+
+// Event notifies subscribers that tasks or contexts have changed.
+type Event struct {
+	Type   string `json:"type"`
+	Action string `json:"action"`
+
+	// ID is the ID of the changed task, if a single task was changed
+	ID string `json:"id,omitempty"`
+
+	// Origin is the ID of the client that made the change, if known
+	Origin string `json:"origin,omitempty"`
+}
+
+// events streams all changes to the client as server-sent events until the
+// client disconnects. The stream is kept open beyond the read and write
+// timeouts of the HTTP server.
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, errorf(http.StatusInternalServerError, "streaming is not supported"))
+		return
+	}
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e := <-events:
+			data, err := json.Marshal(e)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", e.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) subscribe() chan Event {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	events := make(chan Event, 16)
+	s.subscribers[events] = struct{}{}
+	return events
+}
+
+func (s *Server) unsubscribe(events chan Event) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	delete(s.subscribers, events)
+}
+
+// publish sends the event to all subscribers. Subscribers that don't keep up
+// miss events rather than blocking the server.
+func (s *Server) publish(e Event) {
+	s.subscribersMu.Lock()
+	defer s.subscribersMu.Unlock()
+	for events := range s.subscribers {
+		select {
+		case events <- e:
+		default:
+		}
+	}
+}
@@ -0,0 +1,601 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+	"github.com/rwirdemann/scheduled/ical"
+	"github.com/rwirdemann/scheduled/week"
+)
+
+// ClientHeader identifies the client that made a change. It is copied to the
+// origin of the resulting event so that clients can ignore their own changes.
+const ClientHeader = "X-Scheduled-Client"
+
+type repository interface {
+	LoadContexts() ([]scheduled.Context, error)
+	LoadTasks() ([]scheduled.Task, error)
+	SaveContexts(contexts []scheduled.Context) error
+	SaveTasks(tasks []scheduled.Task) error
+	LoadArchive() ([]scheduled.ArchivedTask, error)
+	SaveArchive(archive []scheduled.ArchivedTask) error
+}
+
+// Server serves the tasks and contexts of a repository as JSON over HTTP and
+// notifies subscribers about changes with server-sent events. The tasks of the
+// current week are also served as an iCalendar feed.
+type Server struct {
+	repository repository
+	clock      date.Clock
+	mux        *http.ServeMux
+
+	// mu serializes all changes to the repository
+	mu sync.Mutex
+
+	subscribersMu sync.Mutex
+	subscribers   map[chan Event]struct{}
+}
+
+// New creates a server for the given repository. The clock tells the current
+// week.
+func New(repository repository, clock date.Clock) *Server {
+	s := &Server{
+		repository:  repository,
+		clock:       clock,
+		mux:         http.NewServeMux(),
+		subscribers: make(map[chan Event]struct{}),
+	}
+	s.mux.HandleFunc("GET /api/tasks", s.listTasks)
+	s.mux.HandleFunc("POST /api/tasks", s.createTask)
+	s.mux.HandleFunc("GET /api/tasks/{id}", s.getTask)
+	s.mux.HandleFunc("PATCH /api/tasks/{id}", s.updateTask)
+	s.mux.HandleFunc("DELETE /api/tasks/{id}", s.deleteTask)
+	s.mux.HandleFunc("POST /api/tasks/{id}/move", s.moveTask)
+	s.mux.HandleFunc("POST /api/tasks/{id}/done", s.completeTask(true))
+	s.mux.HandleFunc("DELETE /api/tasks/{id}/done", s.completeTask(false))
+	s.mux.HandleFunc("GET /api/contexts", s.listContexts)
+	s.mux.HandleFunc("GET /api/calendar.ics", s.calendar)
+	s.mux.HandleFunc("POST /api/contexts", s.createContext)
+	s.mux.HandleFunc("GET /api/store/tasks", loadStored(repository.LoadTasks))
+	s.mux.HandleFunc("PUT /api/store/tasks", saveStored(s, EventTasks, repository.LoadTasks, repository.SaveTasks))
+	s.mux.HandleFunc("GET /api/store/contexts", loadStored(repository.LoadContexts))
+	s.mux.HandleFunc("PUT /api/store/contexts", saveStored(s, EventContexts, repository.LoadContexts, repository.SaveContexts))
+	s.mux.HandleFunc("GET /api/store/archive", loadStored(repository.LoadArchive))
+	s.mux.HandleFunc("PUT /api/store/archive", saveStored(s, EventArchive, repository.LoadArchive, repository.SaveArchive))
+	s.mux.HandleFunc("GET /api/events", s.events)
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := checkRequest(r); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// checkRequest rejects requests that a web page in the browser could have
+// sent on behalf of the user. Hosts must be localhost or an IP address, so
+// that other domains can't be rebound to the server, pages of other origins
+// must not make requests, and requests that change data must be JSON, which
+// browsers don't send across origins without asking the server first.
+func checkRequest(r *http.Request) error {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	if host != "localhost" && net.ParseIP(strings.Trim(host, "[]")) == nil {
+		return errorf(http.StatusForbidden, "host '%s' is not allowed", r.Host)
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			return errorf(http.StatusForbidden, "origin '%s' is not allowed", origin)
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	if t, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || t != "application/json" {
+		return errorf(http.StatusUnsupportedMediaType, "Content-Type must be application/json")
+	}
+	return nil
+}
+
+// taskRequest is the body of requests that create or update a task. Fields
+// that are not set are left unchanged.
+type taskRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+	Day         *int    `json:"day"`
+	Context     *int    `json:"context"`
+
+	// Recurrence is a rule as understood by scheduled.ParseRecurrence
+	Recurrence *string `json:"recurrence"`
+
+	// Pin pins the task to the date of its day in the current week, or
+	// unpins it if false
+	Pin *bool `json:"pin"`
+}
+
+type moveRequest struct {
+	Day int `json:"day"`
+}
+
+type contextRequest struct {
+	Name string `json:"name"`
+}
+
+// statusError is an error that is reported with the given HTTP status.
+type statusError struct {
+	status int
+	msg    string
+}
+
+func (e statusError) Error() string {
+	return e.msg
+}
+
+func errorf(status int, format string, a ...any) error {
+	return statusError{status: status, msg: fmt.Sprintf(format, a...)}
+}
+
+func (s *Server) listTasks(w http.ResponseWriter, r *http.Request) {
+	wk, err := s.week()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	tasks := []scheduled.Task{}
+	for _, i := range wk.View() {
+		tasks = append(tasks, wk.Task(i))
+	}
+	writeJSON(w, http.StatusOK, tasks)
+}
+
+func (s *Server) getTask(w http.ResponseWriter, r *http.Request) {
+	wk, err := s.week()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	i, err := find(wk, r.PathValue("id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, wk.Task(i))
+}
+
+func (s *Server) createTask(w http.ResponseWriter, r *http.Request) {
+	var req taskRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		writeError(w, errorf(http.StatusBadRequest, "please enter a title"))
+		return
+	}
+
+	var t scheduled.Task
+	err := s.change(r, "created", func(wk *week.Week, contexts []scheduled.Context) (string, error) {
+		t.Context = scheduled.ContextNone.ID
+		if err := apply(wk, contexts, &t, req); err != nil {
+			return "", err
+		}
+		t = wk.Add(t)
+		return t.ID, nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, t)
+}
+
+func (s *Server) updateTask(w http.ResponseWriter, r *http.Request) {
+	var req taskRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Day != nil {
+		writeError(w, errorf(http.StatusBadRequest, "use move to change the day of a task"))
+		return
+	}
+
+	var t scheduled.Task
+	err := s.change(r, "updated", func(wk *week.Week, contexts []scheduled.Context) (string, error) {
+		i, err := find(wk, r.PathValue("id"))
+		if err != nil {
+			return "", err
+		}
+		if err := apply(wk, contexts, &wk.Tasks[i], req); err != nil {
+			return "", err
+		}
+		t = wk.Task(i)
+		return t.ID, nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+// deleteTask deletes a task that is done and archives it once the tasks are
+// saved. Open tasks are only deleted with ?force=true.
+func (s *Server) deleteTask(w http.ResponseWriter, r *http.Request) {
+	force := r.URL.Query().Get("force") == "true"
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var archived *scheduled.ArchivedTask
+	err := s.changeLocked(r, "deleted", func(wk *week.Week, contexts []scheduled.Context) (string, error) {
+		i, err := find(wk, r.PathValue("id"))
+		if err != nil {
+			return "", err
+		}
+		t := wk.Task(i)
+		if !t.Done && !force {
+			return "", errorf(http.StatusConflict, "'%s' is not done, use ?force=true to delete it anyway", t.Name)
+		}
+		if t.Done {
+			a := scheduled.Archive(t, s.clock.Now())
+			archived = &a
+		}
+		id := wk.Tasks[i].ID
+		wk.Remove(i)
+		return id, nil
+	})
+	if err == nil && archived != nil {
+		err = s.archive(r, *archived)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) moveTask(w http.ResponseWriter, r *http.Request) {
+	var req moveRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	if req.Day < 0 || req.Day > 7 {
+		writeError(w, errorf(http.StatusBadRequest, "invalid day %d", req.Day))
+		return
+	}
+
+	var t scheduled.Task
+	err := s.change(r, "moved", func(wk *week.Week, contexts []scheduled.Context) (string, error) {
+		i, err := find(wk, r.PathValue("id"))
+		if err != nil {
+			return "", err
+		}
+		if err := wk.Move(i, req.Day); err != nil {
+			return "", errorf(http.StatusConflict, "%v", err)
+		}
+		t = wk.Task(i)
+		return t.ID, nil
+	})
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, t)
+}
+
+func (s *Server) completeTask(done bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		action := "completed"
+		if !done {
+			action = "reopened"
+		}
+		var t scheduled.Task
+		err := s.change(r, action, func(wk *week.Week, contexts []scheduled.Context) (string, error) {
+			i, err := find(wk, r.PathValue("id"))
+			if err != nil {
+				return "", err
+			}
+			wk.SetDone(i, done)
+			t = wk.Task(i)
+			return t.ID, nil
+		})
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	}
+}
+
+func (s *Server) listContexts(w http.ResponseWriter, r *http.Request) {
+	contexts, err := s.repository.LoadContexts()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, contexts)
+}
+
+// calendar serves the tasks of the current week as iCalendar to-dos, so that
+// calendar clients can subscribe to them.
+func (s *Server) calendar(w http.ResponseWriter, r *http.Request) {
+	wk, err := s.week()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	contexts, err := s.repository.LoadContexts()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	_, _ = w.Write([]byte(ical.Format(wk, contexts, s.clock.Now())))
+}
+
+func (s *Server) createContext(w http.ResponseWriter, r *http.Request) {
+	var req contextRequest
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		writeError(w, errorf(http.StatusBadRequest, "Context must not be empty"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	contexts, err := s.repository.LoadContexts()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	maxID := 1
+	for _, c := range contexts {
+		if strings.EqualFold(c.Name, name) {
+			writeError(w, errorf(http.StatusConflict, "Context '%s' does already exist", name))
+			return
+		}
+		maxID = max(maxID, c.ID)
+	}
+	c := scheduled.Context{ID: maxID + 1, Name: name}
+	if err := s.repository.SaveContexts(append(contexts, c)); err != nil {
+		writeError(w, err)
+		return
+	}
+	s.publish(Event{Type: EventContexts, Action: "created", Origin: r.Header.Get(ClientHeader)})
+	writeJSON(w, http.StatusCreated, c)
+}
+
+// loadStored serves the value loaded by load. The ETag header holds its
+// version, which is passed as If-Match to saveStored.
+func loadStored[T any](load func() (T, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		v, err := load()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("ETag", version(v))
+		writeJSON(w, http.StatusOK, v)
+	}
+}
+
+// saveStored replaces the value with the one in the request and notifies
+// subscribers with an event of the given type if it changed. It fails with
+// 409 Conflict if the If-Match header is set and the value was changed since
+// that version.
+func saveStored[T any](s *Server, eventType string, load func() (T, error), save func(T) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var v T
+		if err := readJSON(r, &v); err != nil {
+			writeError(w, err)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		current, err := load()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := checkVersion(r, current); err != nil {
+			writeError(w, err)
+			return
+		}
+		if err := save(v); err != nil {
+			writeError(w, err)
+			return
+		}
+		if !reflect.DeepEqual(current, v) {
+			s.publish(Event{Type: eventType, Action: "replaced", Origin: r.Header.Get(ClientHeader)})
+		}
+		saved, err := load()
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		w.Header().Set("ETag", version(saved))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// version returns the entity tag of the stored value.
+func version(v any) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// checkVersion fails if the request is made for another version than the
+// current one.
+func checkVersion(r *http.Request, current any) error {
+	match := r.Header.Get("If-Match")
+	if match == "" || match == "*" || match == version(current) {
+		return nil
+	}
+	return errorf(http.StatusConflict, "modified by another client")
+}
+
+// week loads the tasks of the repository for the current week.
+func (s *Server) week() (*week.Week, error) {
+	tasks, err := s.repository.LoadTasks()
+	if err != nil {
+		return nil, err
+	}
+	return week.New(tasks, s.clock.Now()), nil
+}
+
+// change applies fn to the tasks of the current week, saves them and notifies
+// subscribers about the changed task. fn returns the ID of the changed task.
+func (s *Server) change(r *http.Request, action string, fn func(wk *week.Week, contexts []scheduled.Context) (string, error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changeLocked(r, action, fn)
+}
+
+// changeLocked is change for callers holding the lock.
+func (s *Server) changeLocked(r *http.Request, action string, fn func(wk *week.Week, contexts []scheduled.Context) (string, error)) error {
+	wk, err := s.week()
+	if err != nil {
+		return err
+	}
+	contexts, err := s.repository.LoadContexts()
+	if err != nil {
+		return err
+	}
+	id, err := fn(wk, contexts)
+	if err != nil {
+		return err
+	}
+	if err := s.repository.SaveTasks(wk.Tasks); err != nil {
+		return err
+	}
+	s.publish(Event{Type: EventTasks, Action: action, ID: id, Origin: r.Header.Get(ClientHeader)})
+	return nil
+}
+
+// archive appends the task to the archive and notifies subscribers. The
+// caller holds the lock.
+func (s *Server) archive(r *http.Request, a scheduled.ArchivedTask) error {
+	archive, err := s.repository.LoadArchive()
+	if err != nil {
+		return err
+	}
+	if err := s.repository.SaveArchive(append(archive, a)); err != nil {
+		return err
+	}
+	s.publish(Event{Type: EventArchive, Action: "archived", ID: a.Task.ID, Origin: r.Header.Get(ClientHeader)})
+	return nil
+}
+
+// apply copies the fields set in req to the task.
+func apply(wk *week.Week, contexts []scheduled.Context, t *scheduled.Task, req taskRequest) error {
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			return errorf(http.StatusBadRequest, "please enter a title")
+		}
+		t.Name = *req.Name
+	}
+	if req.Description != nil {
+		t.Desc = *req.Description
+	}
+	if req.Day != nil {
+		if *req.Day < 0 || *req.Day > 7 {
+			return errorf(http.StatusBadRequest, "invalid day %d", *req.Day)
+		}
+		t.Day = *req.Day
+	}
+	if req.Context != nil {
+		found := false
+		for _, c := range contexts {
+			found = found || c.ID == *req.Context
+		}
+		if !found {
+			return errorf(http.StatusBadRequest, "context %d not found", *req.Context)
+		}
+		t.Context = *req.Context
+	}
+	if req.Recurrence != nil {
+		r, err := scheduled.ParseRecurrence(*req.Recurrence)
+		if err != nil {
+			return errorf(http.StatusBadRequest, "%v", err)
+		}
+		if r.String() != t.Recurrence.String() {
+			t.Recurrence = wk.Anchor(r)
+			t.Unpin()
+		}
+	}
+	if req.Pin == nil {
+		return nil
+	}
+	d, pinned := t.PinnedDate()
+	switch {
+	case *req.Pin && t.Day == 0:
+		return errorf(http.StatusBadRequest, "Inbox tasks can not be pinned")
+	case *req.Pin:
+		t.Pin(wk.DateOf(t.Day))
+		t.Recurrence = nil
+	case pinned:
+		// The task rolls on the weekday of its date and stays done in its week
+		done := t.Done
+		t.Day = scheduled.Weekday(d)
+		t.Unpin()
+		t.SetDoneOn(d, done)
+		t.Done = false
+	}
+	return nil
+}
+
+// find returns the index of the task with the given ID.
+func find(wk *week.Week, id string) (int, error) {
+	for i, t := range wk.Tasks {
+		if t.ID == id {
+			return i, nil
+		}
+	}
+	return -1, errorf(http.StatusNotFound, "task '%s' not found", id)
+}
+
+func readJSON(r *http.Request, v any) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return errorf(http.StatusBadRequest, "invalid request: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	var se statusError
+	if errors.As(err, &se) {
+		status = se.status
+	}
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
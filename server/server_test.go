@@ -0,0 +1,516 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+)
+
+// testClock is on Wednesday of week 10 in 2026.
+var testClock = date.Fixed(time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local))
+
+// Mock repository for testing
+type mockRepository struct {
+	mu       sync.Mutex
+	tasks    []scheduled.Task
+	contexts []scheduled.Context
+	archive  []scheduled.ArchivedTask
+
+	// saveErr is returned by SaveTasks if set
+	saveErr error
+}
+
+func (m *mockRepository) LoadTasks() ([]scheduled.Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]scheduled.Task(nil), m.tasks...), nil
+}
+
+func (m *mockRepository) SaveTasks(tasks []scheduled.Task) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.saveErr != nil {
+		return m.saveErr
+	}
+	m.tasks = tasks
+	return nil
+}
+
+func (m *mockRepository) LoadContexts() ([]scheduled.Context, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]scheduled.Context(nil), m.contexts...), nil
+}
+
+func (m *mockRepository) SaveContexts(contexts []scheduled.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.contexts = contexts
+	return nil
+}
+
+func (m *mockRepository) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]scheduled.ArchivedTask(nil), m.archive...), nil
+}
+
+func (m *mockRepository) SaveArchive(archive []scheduled.ArchivedTask) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.archive = archive
+	return nil
+}
+
+func newTestRepository() *mockRepository {
+	return &mockRepository{
+		tasks: []scheduled.Task{
+			{ID: "inbox", Name: "Inbox task", Day: 0},
+			{ID: "tuesday", Name: "Tuesday task", Day: 2},
+		},
+		contexts: []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}},
+	}
+}
+
+// request sends a request to the handler and decodes the response into
+// result if it is not nil.
+func request(t *testing.T, h http.Handler, method, path, body string, result any) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Host = "localhost:7070"
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if result != nil && rec.Code < 300 {
+		if err := json.NewDecoder(rec.Body).Decode(result); err != nil {
+			t.Fatalf("%s %s: invalid response: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestServer_ListTasks(t *testing.T) {
+	s := New(newTestRepository(), testClock)
+
+	var tasks []scheduled.Task
+	if code := request(t, s, "GET", "/api/tasks", "", &tasks); code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", code)
+	}
+	if len(tasks) != 2 || tasks[0].ID != "inbox" || tasks[1].ID != "tuesday" {
+		t.Errorf("tasks = %+v, want inbox and tuesday task", tasks)
+	}
+}
+
+func TestServer_Calendar(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/calendar.ics", nil)
+	req.Host = "localhost:7070"
+	rec := httptest.NewRecorder()
+	New(newTestRepository(), testClock).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if got := rec.Header().Get("Content-Type"); !strings.HasPrefix(got, "text/calendar") {
+		t.Errorf("Content-Type = %s, want text/calendar", got)
+	}
+	body := rec.Body.String()
+	for _, line := range []string{"UID:inbox@scheduled", "UID:tuesday@scheduled", "DTSTART;VALUE=DATE:20260303", "RRULE:FREQ=WEEKLY;BYDAY=TU"} {
+		if !strings.Contains(body, line+"\r\n") {
+			t.Errorf("body = %q, want it to contain %s", body, line)
+		}
+	}
+}
+
+func TestServer_CreateUpdateMoveComplete(t *testing.T) {
+	repo := newTestRepository()
+	s := New(repo, testClock)
+
+	var created scheduled.Task
+	code := request(t, s, "POST", "/api/tasks", `{"name":"Write report","day":3,"context":2}`, &created)
+	if code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201", code)
+	}
+	if created.ID == "" || created.Day != 3 || created.Context != 2 {
+		t.Errorf("created = %+v", created)
+	}
+
+	var updated scheduled.Task
+	code = request(t, s, "PATCH", "/api/tasks/"+created.ID, `{"name":"Write weekly report","description":"notes"}`, &updated)
+	if code != http.StatusOK {
+		t.Fatalf("update status = %d, want 200", code)
+	}
+	if updated.Name != "Write weekly report" || updated.Desc != "notes" || updated.Context != 2 {
+		t.Errorf("updated = %+v", updated)
+	}
+
+	var moved scheduled.Task
+	if code := request(t, s, "POST", "/api/tasks/"+created.ID+"/move", `{"day":5}`, &moved); code != http.StatusOK {
+		t.Fatalf("move status = %d, want 200", code)
+	}
+	if moved.Day != 5 {
+		t.Errorf("Day = %d, want 5", moved.Day)
+	}
+
+	var done scheduled.Task
+	if code := request(t, s, "POST", "/api/tasks/"+created.ID+"/done", "", &done); code != http.StatusOK {
+		t.Fatalf("done status = %d, want 200", code)
+	}
+	if !done.Done || len(done.DoneWeeks) != 1 || done.DoneWeeks[0] != "2026-W10" {
+		t.Errorf("done = %+v, want done in the current week", done)
+	}
+
+	// Done of rolling tasks is only stored per week
+	stored, _ := repo.LoadTasks()
+	if stored[2].Done {
+		t.Error("stored rolling task should not carry the done flag")
+	}
+
+	if code := request(t, s, "DELETE", "/api/tasks/"+created.ID+"/done", "", &done); code != http.StatusOK || done.Done {
+		t.Errorf("reopen status = %d, done = %v", code, done.Done)
+	}
+	if code := request(t, s, "DELETE", "/api/tasks/"+created.ID, "", nil); code != http.StatusConflict {
+		t.Errorf("delete open task status = %d, want 409", code)
+	}
+	if code := request(t, s, "DELETE", "/api/tasks/"+created.ID+"?force=true", "", nil); code != http.StatusNoContent {
+		t.Errorf("delete status = %d, want 204", code)
+	}
+	if len(repo.tasks) != 2 {
+		t.Errorf("len(tasks) = %d, want 2", len(repo.tasks))
+	}
+}
+
+func TestServer_PinAndUnpin(t *testing.T) {
+	repo := newTestRepository()
+	s := New(repo, testClock)
+
+	var pinned scheduled.Task
+	if code := request(t, s, "PATCH", "/api/tasks/tuesday", `{"pin":true}`, &pinned); code != http.StatusOK || pinned.Date != "2026-03-03" {
+		t.Fatalf("pin = %d, %+v, want the task pinned to Tuesday", code, pinned)
+	}
+	if code := request(t, s, "POST", "/api/tasks/tuesday/done", "", nil); code != http.StatusOK {
+		t.Fatalf("done status = %d, want 200", code)
+	}
+
+	var unpinned scheduled.Task
+	if code := request(t, s, "PATCH", "/api/tasks/tuesday", `{"pin":false}`, &unpinned); code != http.StatusOK {
+		t.Fatalf("unpin status = %d, want 200", code)
+	}
+	if unpinned.Date != "" || unpinned.Day != 2 || !unpinned.Done {
+		t.Errorf("unpinned = %+v, want a rolling Tuesday task done this week", unpinned)
+	}
+	if stored := repo.tasks[1]; stored.Done || len(stored.DoneWeeks) != 1 {
+		t.Errorf("stored %+v, want the done state kept per week", stored)
+	}
+}
+
+func TestServer_DeleteArchivesDoneTasks(t *testing.T) {
+	repo := newTestRepository()
+	s := New(repo, testClock)
+
+	if code := request(t, s, "POST", "/api/tasks/tuesday/done", "", nil); code != http.StatusOK {
+		t.Fatalf("done status = %d, want 200", code)
+	}
+	if code := request(t, s, "DELETE", "/api/tasks/tuesday", "", nil); code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", code)
+	}
+	if len(repo.tasks) != 1 || len(repo.archive) != 1 {
+		t.Fatalf("tasks = %v, archive = %v, want the done task archived", repo.tasks, repo.archive)
+	}
+	if a := repo.archive[0]; a.Task.ID != "tuesday" || !a.Completed.Equal(testClock.Now()) {
+		t.Errorf("archived %+v, want tuesday completed at %v", a, testClock.Now())
+	}
+}
+
+func TestServer_DeleteArchivesAfterSaving(t *testing.T) {
+	repo := newTestRepository()
+	s := New(repo, testClock)
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+	if code := request(t, s, "POST", "/api/tasks/tuesday/done", "", nil); code != http.StatusOK {
+		t.Fatalf("done status = %d, want 200", code)
+	}
+	<-events
+
+	repo.saveErr = scheduled.ErrModified
+	if code := request(t, s, "DELETE", "/api/tasks/tuesday", "", nil); code == http.StatusNoContent {
+		t.Fatal("delete should fail if the tasks can't be saved")
+	}
+	if len(repo.archive) != 0 {
+		t.Fatalf("archive = %v, want nothing archived", repo.archive)
+	}
+
+	repo.saveErr = nil
+	if code := request(t, s, "DELETE", "/api/tasks/tuesday", "", nil); code != http.StatusNoContent {
+		t.Fatalf("delete status = %d, want 204", code)
+	}
+	if len(repo.archive) != 1 {
+		t.Errorf("archive = %v, want the task archived once", repo.archive)
+	}
+	for _, want := range []string{EventTasks, EventArchive} {
+		if e := <-events; e.Type != want || e.ID != "tuesday" {
+			t.Errorf("event = %+v, want %s of tuesday", e, want)
+		}
+	}
+}
+
+func TestServer_Errors(t *testing.T) {
+	s := New(newTestRepository(), testClock)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"unknown task", "GET", "/api/tasks/nope", "", http.StatusNotFound},
+		{"missing title", "POST", "/api/tasks", `{"day":1}`, http.StatusBadRequest},
+		{"invalid json", "POST", "/api/tasks", `{`, http.StatusBadRequest},
+		{"unknown context", "POST", "/api/tasks", `{"name":"x","context":42}`, http.StatusBadRequest},
+		{"invalid rule", "PATCH", "/api/tasks/inbox", `{"recurrence":"daily"}`, http.StatusBadRequest},
+		{"pin inbox task", "PATCH", "/api/tasks/inbox", `{"pin":true}`, http.StatusBadRequest},
+		{"invalid day", "POST", "/api/tasks/inbox/move", `{"day":8}`, http.StatusBadRequest},
+		{"duplicate context", "POST", "/api/contexts", `{"name":"Work"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := request(t, s, tt.method, tt.path, tt.body, nil); code != tt.want {
+				t.Errorf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_RejectsForeignRequests(t *testing.T) {
+	s := New(newTestRepository(), testClock)
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		origin      string
+		contentType string
+		want        int
+	}{
+		{"localhost", "GET", "localhost:7070", "", "", http.StatusOK},
+		{"ip address", "GET", "127.0.0.1:7070", "", "", http.StatusOK},
+		{"same origin", "POST", "localhost:7070", "http://localhost:7070", "application/json; charset=utf-8", http.StatusOK},
+		{"rebound domain", "GET", "attacker.example:7070", "", "", http.StatusForbidden},
+		{"foreign origin", "GET", "localhost:7070", "http://attacker.example", "", http.StatusForbidden},
+		{"form post", "POST", "localhost:7070", "", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"no content type", "POST", "localhost:7070", "", "", http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := "/api/tasks"
+			if tt.method == "POST" {
+				path = "/api/tasks/tuesday/done"
+			}
+			req := httptest.NewRequest(tt.method, path, nil)
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Events(t *testing.T) {
+	ts := httptest.NewServer(New(newTestRepository(), testClock))
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/api/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %s", ct)
+	}
+
+	req, _ := http.NewRequest("POST", ts.URL+"/api/tasks/tuesday/done", nil)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(ClientHeader, "test-client")
+	if _, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		var e Event
+		if err := json.Unmarshal([]byte(data), &e); err != nil {
+			t.Fatal(err)
+		}
+		want := Event{Type: EventTasks, Action: "completed", ID: "tuesday", Origin: "test-client"}
+		if e != want {
+			t.Errorf("event = %+v, want %+v", e, want)
+		}
+		return
+	}
+	t.Fatal("no event received")
+}
+
+func TestClient_RoundTripAndChanges(t *testing.T) {
+	repo := newTestRepository()
+	ts := httptest.NewServer(New(repo, testClock))
+	defer ts.Close()
+
+	// The clients keep their event streams open
+	defer ts.CloseClientConnections()
+
+	a, b := NewClient(ts.URL), NewClient(ts.URL+"/")
+	changesA, changesB := a.Changes(), b.Changes()
+
+	// Wait until both clients are subscribed
+	s := ts.Config.Handler.(*Server)
+	for deadline := time.Now().Add(2 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		s.subscribersMu.Lock()
+		n := len(s.subscribers)
+		s.subscribersMu.Unlock()
+		if n == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("clients did not subscribe")
+		}
+	}
+
+	tasks, err := a.LoadTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasks[0].Name = "Renamed"
+	if err := a.SaveTasks(tasks); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-changesB:
+	case <-time.After(2 * time.Second):
+		t.Fatal("other client was not notified")
+	}
+	select {
+	case <-changesA:
+		t.Error("client was notified about its own change")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	loaded, err := b.LoadTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded[0].Name != "Renamed" {
+		t.Errorf("Name = %s, want Renamed", loaded[0].Name)
+	}
+
+	contexts, err := b.LoadContexts()
+	if err != nil || len(contexts) != 2 {
+		t.Fatalf("LoadContexts() = %v, %v", contexts, err)
+	}
+	if err := b.SaveContexts(contexts[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if len(repo.contexts) != 1 {
+		t.Errorf("len(contexts) = %d, want 1", len(repo.contexts))
+	}
+
+	archive := []scheduled.ArchivedTask{scheduled.Archive(scheduled.Task{ID: "done", Name: "Done", Done: true}, testClock.Now())}
+	if err := b.SaveArchive(archive); err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := a.LoadArchive(); err != nil || len(loaded) != 1 || !loaded[0].Completed.Equal(archive[0].Completed) {
+		t.Errorf("LoadArchive() = %v, %v, want %v", loaded, err, archive)
+	}
+}
+
+func TestClient_SaveAfterOtherClientFailsWithErrModified(t *testing.T) {
+	repo := newTestRepository()
+	ts := httptest.NewServer(New(repo, testClock))
+	defer ts.Close()
+
+	a, b := NewClient(ts.URL), NewClient(ts.URL)
+	tasksA, err := a.LoadTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tasksB, err := b.LoadTasks()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tasksA[0].Name = "Renamed by a"
+	if err := a.SaveTasks(tasksA); err != nil {
+		t.Fatal(err)
+	}
+	tasksB[1].Name = "Renamed by b"
+	if err := b.SaveTasks(tasksB); !errors.Is(err, scheduled.ErrModified) {
+		t.Fatalf("SaveTasks() error = %v, want ErrModified", err)
+	}
+	if repo.tasks[0].Name != "Renamed by a" {
+		t.Errorf("Name = %s, want the change of a to be kept", repo.tasks[0].Name)
+	}
+
+	// After loading again, b saves based on the current version
+	if _, err := b.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.SaveTasks(tasksB); err != nil {
+		t.Errorf("SaveTasks() after reload error = %v", err)
+	}
+
+	// The version of a is outdated by the save of b
+	if err := a.SaveTasks(tasksA); !errors.Is(err, scheduled.ErrModified) {
+		t.Errorf("SaveTasks() of a error = %v, want ErrModified", err)
+	}
+}
+
+func TestClient_ReportsServerErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, errorf(http.StatusInternalServerError, "disk full"))
+	}))
+	defer ts.Close()
+
+	err := NewClient(ts.URL).SaveTasks(nil)
+	if err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Errorf("SaveTasks() error = %v, want server error", err)
+	}
+}
+
+func TestServer_SaveWithoutChangesSendsNoEvent(t *testing.T) {
+	repo := newTestRepository()
+	s := New(repo, testClock)
+	events := s.subscribe()
+	defer s.unsubscribe(events)
+
+	body, _ := json.Marshal(repo.tasks)
+	if code := request(t, s, "PUT", "/api/store/tasks", string(bytes.TrimSpace(body)), nil); code != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204", code)
+	}
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %+v", e)
+	default:
+	}
+}
@@ -0,0 +1,531 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	_ "modernc.org/sqlite"
+)
+
+// pollInterval is the time between two checks for changes made to the
+// database by other programs.
+const pollInterval = 2 * time.Second
+
+const schema = `
+CREATE TABLE IF NOT EXISTS tasks (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	day         INTEGER NOT NULL,
+	done        INTEGER NOT NULL,
+	pos         INTEGER NOT NULL,
+	context     INTEGER NOT NULL,
+	date        TEXT NOT NULL DEFAULT '',
+	done_weeks  TEXT NOT NULL DEFAULT '',
+	recurrence  TEXT NOT NULL DEFAULT '',
+	completed   TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS contexts (
+	id   INTEGER PRIMARY KEY,
+	name TEXT NOT NULL
+);
+CREATE TABLE IF NOT EXISTS task_history (
+	seq        INTEGER PRIMARY KEY AUTOINCREMENT,
+	task_id    TEXT NOT NULL,
+	changed_at TEXT NOT NULL,
+	action     TEXT NOT NULL,
+	task       TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS task_history_task_id ON task_history (task_id);
+CREATE TABLE IF NOT EXISTS archive (
+	task_id   TEXT PRIMARY KEY,
+	completed TEXT NOT NULL,
+	task      TEXT NOT NULL
+);
+`
+
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+)
+
+// Change is an entry in the history of a task.
+type Change struct {
+	Time   time.Time      `json:"time"`
+	Action string         `json:"action"`
+	Task   scheduled.Task `json:"task"`
+}
+
+// Repository stores tasks and contexts in a SQLite database. Saves only write
+// the tasks and contexts that changed and record each change of a task in its
+// history.
+type Repository struct {
+	db   *sql.DB
+	conn *sql.Conn
+
+	mu sync.Mutex
+
+	// version is the data version of the database as last loaded or saved.
+	// It changes whenever another connection commits a change.
+	version  int64
+	reported int64
+
+	once    sync.Once
+	changes chan struct{}
+}
+
+// Open opens the database with the given file name and creates the tables if
+// they don't exist.
+func Open(filename string) (*Repository, error) {
+	// Transactions take the write lock right away, so that the check for
+	// changes made by other programs and the write can't be interleaved
+	db, err := sql.Open("sqlite", filename+"?_txlock=immediate")
+	if err != nil {
+		return nil, err
+	}
+
+	// The data version is tracked per connection, so all statements use the
+	// same one
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	r := &Repository{db: db, conn: conn, changes: make(chan struct{}, 1)}
+	for _, stmt := range []string{"PRAGMA busy_timeout = 5000", "PRAGMA journal_mode = WAL", schema} {
+		if _, err := conn.ExecContext(context.Background(), stmt); err != nil {
+			_ = r.Close()
+			return nil, fmt.Errorf("failed to open %s: %w", filename, err)
+		}
+	}
+	if err := addColumn(conn, "tasks", "completed", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		_ = r.Close()
+		return nil, fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	return r, nil
+}
+
+// addColumn adds the column to tables created before it was part of the
+// schema.
+func addColumn(conn *sql.Conn, table, column, definition string) error {
+	var n int
+	err := conn.QueryRowContext(context.Background(),
+		"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", table, column).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = conn.ExecContext(context.Background(), fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
+// Close closes the database.
+func (r *Repository) Close() error {
+	_ = r.conn.Close()
+	return r.db.Close()
+}
+
+// LoadTasks loads and returns all tasks.
+func (r *Repository) LoadTasks() ([]scheduled.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tasks, err := loadTasks(r.conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tasks: %w", err)
+	}
+	return tasks, r.remember()
+}
+
+// SaveTasks saves the given tasks. Only new, changed and removed tasks are
+// written.
+func (r *Repository) SaveTasks(tasks []scheduled.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.update(func(tx *sql.Tx) error {
+		existing, err := loadTasks(tx)
+		if err != nil {
+			return err
+		}
+		stored := make(map[string]scheduled.Task, len(existing))
+		for _, t := range existing {
+			stored[t.ID] = t
+		}
+
+		now := time.Now()
+		for _, t := range tasks {
+			old, ok := stored[t.ID]
+			delete(stored, t.ID)
+			switch {
+			case !ok:
+				err = insertTask(tx, t, now)
+			case !reflect.DeepEqual(old, t):
+				err = updateTask(tx, t, now)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		for _, t := range stored {
+			if err := deleteTask(tx, t, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save tasks: %w", err)
+	}
+	return nil
+}
+
+// LoadContexts loads and returns all contexts, starting with the none
+// context.
+func (r *Repository) LoadContexts() ([]scheduled.Context, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows, err := r.conn.QueryContext(context.Background(), "SELECT id, name FROM contexts ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load contexts: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	contexts := []scheduled.Context{scheduled.ContextNone}
+	for rows.Next() {
+		var c scheduled.Context
+		if err := rows.Scan(&c.ID, &c.Name); err != nil {
+			return nil, fmt.Errorf("failed to load contexts: %w", err)
+		}
+		if c.ID != scheduled.ContextNone.ID {
+			contexts = append(contexts, c)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load contexts: %w", err)
+	}
+	return contexts, r.remember()
+}
+
+// SaveContexts saves the given contexts.
+func (r *Repository) SaveContexts(contexts []scheduled.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.update(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM contexts"); err != nil {
+			return err
+		}
+		for _, c := range contexts {
+			if c.ID == scheduled.ContextNone.ID {
+				continue
+			}
+			if _, err := tx.Exec("INSERT INTO contexts (id, name) VALUES (?, ?)", c.ID, c.Name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save contexts: %w", err)
+	}
+	return nil
+}
+
+// LoadArchive loads and returns all archived tasks, oldest first.
+func (r *Repository) LoadArchive() ([]scheduled.ArchivedTask, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows, err := r.conn.QueryContext(context.Background(), "SELECT completed, task FROM archive ORDER BY completed, rowid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load archive: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	archive := []scheduled.ArchivedTask{}
+	for rows.Next() {
+		var a scheduled.ArchivedTask
+		var completed, task string
+		if err := rows.Scan(&completed, &task); err != nil {
+			return nil, fmt.Errorf("failed to load archive: %w", err)
+		}
+		if a.Completed, err = time.Parse(time.RFC3339, completed); err != nil {
+			return nil, fmt.Errorf("failed to load archive: %w", err)
+		}
+		if err := json.Unmarshal([]byte(task), &a.Task); err != nil {
+			return nil, fmt.Errorf("failed to load archive: %w", err)
+		}
+		a.Completed = a.Completed.Local()
+		archive = append(archive, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load archive: %w", err)
+	}
+	return archive, r.remember()
+}
+
+// SaveArchive saves the given archived tasks. Only new, changed and restored
+// tasks are written.
+func (r *Repository) SaveArchive(archive []scheduled.ArchivedTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.update(func(tx *sql.Tx) error {
+		rows, err := tx.Query("SELECT task_id, completed, task FROM archive")
+		if err != nil {
+			return err
+		}
+		stored := make(map[string][2]string)
+		for rows.Next() {
+			var id, completed, task string
+			if err := rows.Scan(&id, &completed, &task); err != nil {
+				_ = rows.Close()
+				return err
+			}
+			stored[id] = [2]string{completed, task}
+		}
+		if err := rows.Close(); err != nil {
+			return err
+		}
+
+		for _, a := range archive {
+			task, err := json.Marshal(a.Task)
+			if err != nil {
+				return err
+			}
+			row := [2]string{a.Completed.Format(time.RFC3339), string(task)}
+			old, ok := stored[a.Task.ID]
+			delete(stored, a.Task.ID)
+			if ok && old == row {
+				continue
+			}
+			if _, err := tx.Exec("INSERT OR REPLACE INTO archive (task_id, completed, task) VALUES (?, ?, ?)",
+				a.Task.ID, row[0], row[1]); err != nil {
+				return err
+			}
+		}
+		for id := range stored {
+			if _, err := tx.Exec("DELETE FROM archive WHERE task_id = ?", id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save archive: %w", err)
+	}
+	return nil
+}
+
+// TaskHistory returns all changes of the task with the given ID, oldest
+// first.
+func (r *Repository) TaskHistory(id string) ([]Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rows, err := r.conn.QueryContext(context.Background(),
+		"SELECT changed_at, action, task FROM task_history WHERE task_id = ? ORDER BY seq", id)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	var changes []Change
+	for rows.Next() {
+		var c Change
+		var changedAt, task string
+		if err := rows.Scan(&changedAt, &c.Action, &task); err != nil {
+			return nil, err
+		}
+		if c.Time, err = time.Parse(time.RFC3339Nano, changedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(task), &c.Task); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// Changes returns a channel that receives a value whenever the database was
+// changed by another program. The database is polled every few seconds.
+func (r *Repository) Changes() <-chan struct{} {
+	r.once.Do(func() {
+		go r.poll()
+	})
+	return r.changes
+}
+
+func (r *Repository) poll() {
+	for range time.Tick(pollInterval) {
+		r.mu.Lock()
+		v, err := dataVersion(r.conn)
+		isNew := err == nil && v != r.version && v != r.reported
+		if isNew {
+			r.reported = v
+		}
+		r.mu.Unlock()
+
+		if isNew {
+			select {
+			case r.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// update runs fn in a transaction unless the database was changed by another
+// program since it was last loaded.
+func (r *Repository) update(fn func(tx *sql.Tx) error) error {
+	tx, err := r.conn.BeginTx(context.Background(), nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	v, err := dataVersion(tx)
+	if err != nil {
+		return err
+	}
+	if r.version != 0 && v != r.version {
+		return scheduled.ErrModified
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return r.remember()
+}
+
+// remember records the current data version as the one known to the
+// repository.
+func (r *Repository) remember() error {
+	v, err := dataVersion(r.conn)
+	if err != nil {
+		return err
+	}
+	r.version = v
+	return nil
+}
+
+// querier is implemented by sql.Conn and sql.Tx.
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+func dataVersion(q querier) (int64, error) {
+	var v int64
+	err := q.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&v)
+	return v, err
+}
+
+func loadTasks(q querier) ([]scheduled.Task, error) {
+	rows, err := q.QueryContext(context.Background(),
+		`SELECT id, name, description, day, done, pos, context, date, done_weeks, recurrence, completed
+		 FROM tasks ORDER BY day, pos, rowid`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+
+	tasks := []scheduled.Task{}
+	for rows.Next() {
+		var t scheduled.Task
+		var doneWeeks, recurrence, completed string
+		if err := rows.Scan(&t.ID, &t.Name, &t.Desc, &t.Day, &t.Done, &t.Pos, &t.Context, &t.Date, &doneWeeks, &recurrence, &completed); err != nil {
+			return nil, err
+		}
+		if doneWeeks != "" {
+			if err := json.Unmarshal([]byte(doneWeeks), &t.DoneWeeks); err != nil {
+				return nil, fmt.Errorf("invalid done weeks of task %s: %w", t.ID, err)
+			}
+		}
+		if recurrence != "" {
+			if err := json.Unmarshal([]byte(recurrence), &t.Recurrence); err != nil {
+				return nil, fmt.Errorf("invalid recurrence of task %s: %w", t.ID, err)
+			}
+		}
+		if completed != "" {
+			c, err := time.Parse(time.RFC3339, completed)
+			if err != nil {
+				return nil, fmt.Errorf("invalid completion time of task %s: %w", t.ID, err)
+			}
+			t.Completed = c.Local()
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, rows.Err()
+}
+
+// columns returns the values of the task columns following the ID.
+func columns(t scheduled.Task) ([]any, error) {
+	var doneWeeks, recurrence, completed string
+	if len(t.DoneWeeks) > 0 {
+		b, err := json.Marshal(t.DoneWeeks)
+		if err != nil {
+			return nil, err
+		}
+		doneWeeks = string(b)
+	}
+	if t.Recurrence != nil {
+		b, err := json.Marshal(t.Recurrence)
+		if err != nil {
+			return nil, err
+		}
+		recurrence = string(b)
+	}
+	if !t.Completed.IsZero() {
+		completed = t.Completed.Format(time.RFC3339)
+	}
+	return []any{t.Name, t.Desc, t.Day, t.Done, t.Pos, t.Context, t.Date, doneWeeks, recurrence, completed}, nil
+}
+
+func insertTask(tx *sql.Tx, t scheduled.Task, now time.Time) error {
+	values, err := columns(t)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`INSERT INTO tasks (id, name, description, day, done, pos, context, date, done_weeks, recurrence, completed)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, append([]any{t.ID}, values...)...)
+	if err != nil {
+		return err
+	}
+	return record(tx, t, ActionCreated, now)
+}
+
+func updateTask(tx *sql.Tx, t scheduled.Task, now time.Time) error {
+	values, err := columns(t)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE tasks SET name = ?, description = ?, day = ?, done = ?, pos = ?, context = ?,
+		date = ?, done_weeks = ?, recurrence = ?, completed = ? WHERE id = ?`, append(values, t.ID)...)
+	if err != nil {
+		return err
+	}
+	return record(tx, t, ActionUpdated, now)
+}
+
+func deleteTask(tx *sql.Tx, t scheduled.Task, now time.Time) error {
+	if _, err := tx.Exec("DELETE FROM tasks WHERE id = ?", t.ID); err != nil {
+		return err
+	}
+	return record(tx, t, ActionDeleted, now)
+}
+
+// record adds the change to the history of the task.
+func record(tx *sql.Tx, t scheduled.Task, action string, now time.Time) error {
+	task, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("INSERT INTO task_history (task_id, changed_at, action, task) VALUES (?, ?, ?, ?)",
+		t.ID, now.Format(time.RFC3339Nano), action, string(task))
+	return err
+}
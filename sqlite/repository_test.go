@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+func openTestRepository(t *testing.T, filename string) *Repository {
+	t.Helper()
+	r, err := Open(filename)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { _ = r.Close() })
+	return r
+}
+
+func TestRepository_SaveAndLoadTasks(t *testing.T) {
+	r := openTestRepository(t, filepath.Join(t.TempDir(), "tasks.db"))
+
+	tasks := []scheduled.Task{
+		{ID: "1", Name: "Rolling", Day: 1, DoneWeeks: []string{"2026-W07"}},
+		{ID: "2", Name: "Pinned", Desc: "notes", Day: 3, Date: "2026-10-14", Context: 2},
+		{ID: "3", Name: "Recurring", Day: 5, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekly, Interval: 2, Start: "2026-10-12"}},
+		{ID: "4", Name: "Done", Done: true, Pos: 1, Completed: time.Date(2026, time.March, 4, 9, 30, 0, 0, time.Local)},
+	}
+	if err := r.SaveTasks(tasks); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+
+	loaded, err := r.LoadTasks()
+	if err != nil {
+		t.Fatalf("LoadTasks() error = %v", err)
+	}
+	byID := make(map[string]scheduled.Task)
+	for _, task := range loaded {
+		byID[task.ID] = task
+	}
+	for _, want := range tasks {
+		if got := byID[want.ID]; !reflect.DeepEqual(got, want) {
+			t.Errorf("Loaded task = %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestOpen_AddsMissingColumns(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.db")
+	db, err := sql.Open("sqlite", filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(`CREATE TABLE tasks (id TEXT PRIMARY KEY, name TEXT NOT NULL, description TEXT NOT NULL DEFAULT '',
+		day INTEGER NOT NULL, done INTEGER NOT NULL, pos INTEGER NOT NULL, context INTEGER NOT NULL,
+		date TEXT NOT NULL DEFAULT '', done_weeks TEXT NOT NULL DEFAULT '', recurrence TEXT NOT NULL DEFAULT '');
+		INSERT INTO tasks (id, name, day, done, pos, context) VALUES ('1', 'Old', 0, 1, 0, 1)`)
+	_ = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := openTestRepository(t, filename)
+	tasks, err := r.LoadTasks()
+	if err != nil || len(tasks) != 1 || tasks[0].Name != "Old" || !tasks[0].Completed.IsZero() {
+		t.Fatalf("LoadTasks() = %+v, %v, want the old task without completion time", tasks, err)
+	}
+	tasks[0].Completed = time.Date(2026, time.March, 4, 9, 30, 0, 0, time.Local)
+	if err := r.SaveTasks(tasks); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+}
+
+func TestRepository_SaveAndLoadContexts(t *testing.T) {
+	r := openTestRepository(t, filepath.Join(t.TempDir(), "tasks.db"))
+
+	contexts, err := r.LoadContexts()
+	if err != nil || len(contexts) != 1 || contexts[0] != scheduled.ContextNone {
+		t.Fatalf("LoadContexts() = %v, %v, want none context only", contexts, err)
+	}
+
+	want := []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "work"}, {ID: 3, Name: "home"}}
+	if err := r.SaveContexts(want); err != nil {
+		t.Fatalf("SaveContexts() error = %v", err)
+	}
+	if contexts, _ = r.LoadContexts(); !reflect.DeepEqual(contexts, want) {
+		t.Errorf("LoadContexts() = %v, want %v", contexts, want)
+	}
+}
+
+func TestRepository_TaskHistory(t *testing.T) {
+	r := openTestRepository(t, filepath.Join(t.TempDir(), "tasks.db"))
+
+	task := scheduled.Task{ID: "1", Name: "Task"}
+	other := scheduled.Task{ID: "2", Name: "Other"}
+	_ = r.SaveTasks([]scheduled.Task{task, other})
+
+	// Unchanged tasks are not written again
+	_ = r.SaveTasks([]scheduled.Task{task, other})
+
+	task.Name = "Renamed"
+	_ = r.SaveTasks([]scheduled.Task{task, other})
+	_ = r.SaveTasks([]scheduled.Task{other})
+
+	changes, err := r.TaskHistory("1")
+	if err != nil {
+		t.Fatalf("TaskHistory() error = %v", err)
+	}
+	var actions []string
+	for _, c := range changes {
+		actions = append(actions, c.Action)
+	}
+	want := []string{ActionCreated, ActionUpdated, ActionDeleted}
+	if !reflect.DeepEqual(actions, want) {
+		t.Fatalf("Actions = %v, want %v", actions, want)
+	}
+	if changes[1].Task.Name != "Renamed" {
+		t.Errorf("Name = %s, want Renamed", changes[1].Task.Name)
+	}
+
+	if changes, _ := r.TaskHistory("2"); len(changes) != 1 {
+		t.Errorf("Expected 1 change of untouched task, got %d", len(changes))
+	}
+}
+
+func TestRepository_SaveTasksRefusesExternalChanges(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tasks.db")
+	r := openTestRepository(t, filename)
+	other := openTestRepository(t, filename)
+
+	if _, err := r.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SaveTasks([]scheduled.Task{{ID: "1", Name: "Theirs"}}); err != nil {
+		t.Fatalf("SaveTasks() error = %v", err)
+	}
+
+	if err := r.SaveTasks([]scheduled.Task{{ID: "2", Name: "Mine"}}); !errors.Is(err, scheduled.ErrModified) {
+		t.Fatalf("SaveTasks() error = %v, want ErrModified", err)
+	}
+
+	// Loading again makes the change known
+	if _, err := r.LoadTasks(); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveTasks([]scheduled.Task{{ID: "1", Name: "Theirs"}, {ID: "2", Name: "Mine"}}); err != nil {
+		t.Errorf("SaveTasks() error = %v", err)
+	}
+}
+
+func TestRepository_SaveAndLoadArchive(t *testing.T) {
+	r := openTestRepository(t, filepath.Join(t.TempDir(), "tasks.db"))
+
+	done := scheduled.Archive(scheduled.Task{ID: "1", Name: "Done", Done: true}, time.Now())
+	other := scheduled.Archive(scheduled.Task{ID: "2", Name: "Other", Done: true, Context: 2}, time.Now())
+	if err := r.SaveArchive([]scheduled.ArchivedTask{done, other}); err != nil {
+		t.Fatalf("SaveArchive() error = %v", err)
+	}
+	if err := r.SaveArchive([]scheduled.ArchivedTask{other}); err != nil {
+		t.Fatalf("SaveArchive() error = %v", err)
+	}
+
+	archive, err := r.LoadArchive()
+	if err != nil {
+		t.Fatalf("LoadArchive() error = %v", err)
+	}
+	if len(archive) != 1 || !reflect.DeepEqual(archive[0].Task, other.Task) || !archive[0].Completed.Equal(other.Completed) {
+		t.Errorf("LoadArchive() = %+v, want %+v", archive, other)
+	}
+}
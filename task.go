@@ -1,6 +1,14 @@
 package scheduled
 
-import "fmt"
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DateFormat is the layout used to store the date of pinned tasks.
+const DateFormat = "2006-01-02"
 
 // Task represents a task in the task list.
 type Task struct {
@@ -11,16 +19,183 @@ type Task struct {
 	Done    bool   `json:"done"`
 	Pos     int    `json:"pos"`
 	Context int    `json:"context"`
+
+	// Date pins the task to a concrete calendar day. Tasks without a date are
+	// rolling and show up on the same weekday in every week.
+	Date string `json:"date,omitempty"`
+
+	// DoneWeeks records the ISO weeks in which a rolling task was completed,
+	// or the dates for tasks that recur on several days of a week. For rolling
+	// tasks Done only reflects the week currently shown.
+	DoneWeeks []string `json:"done_weeks,omitempty"`
+
+	// Recurrence overrides the default rhythm of rolling tasks, which repeat
+	// on the same weekday every week.
+	Recurrence *Recurrence `json:"recurrence,omitempty"`
+
+	// Completed is the time the task was last checked. Tasks that are not
+	// done in any week have none.
+	Completed time.Time `json:"completed,omitzero"`
 }
 
 func (i Task) Title() string {
 	checkbox := "○ "
+	name := i.Name
+	if i.Pinned() {
+		name += " ⚑"
+	} else if i.Recurrence != nil {
+		name += " ↻"
+	}
 	if i.Done {
 		// Gray color using ANSI escape code
-		return "\x1b[90m✓ " + fmt.Sprintf("%s", i.Name+"\x1b[0m")
+		return "\x1b[90m✓ " + fmt.Sprintf("%s", name+"\x1b[0m")
 	}
-	return fmt.Sprintf("%s%s", checkbox, i.Name)
+	return fmt.Sprintf("%s%s", checkbox, name)
+}
+
+// Description returns the first line of the task description.
+func (i Task) Description() string {
+	line, _, _ := strings.Cut(i.Desc, "\n")
+	return line
 }
 
-func (i Task) Description() string { return "hello" }
 func (i Task) FilterValue() string { return i.Name }
+
+// Pinned returns true if the task is pinned to a concrete date.
+func (i Task) Pinned() bool {
+	_, ok := i.PinnedDate()
+	return ok
+}
+
+// PinnedDate returns the date the task is pinned to. The second return value
+// is false for rolling tasks.
+func (i Task) PinnedDate() (time.Time, bool) {
+	if i.Date == "" {
+		return time.Time{}, false
+	}
+	d, err := time.ParseInLocation(DateFormat, i.Date, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return d, true
+}
+
+// Pin pins the task to the given date.
+func (i *Task) Pin(d time.Time) {
+	i.Date = d.Format(DateFormat)
+}
+
+// Unpin turns the task back into a rolling task.
+func (i *Task) Unpin() {
+	i.Date = ""
+}
+
+// Rolling returns true if the task is scheduled for a weekday without being
+// pinned to a date.
+func (i Task) Rolling() bool {
+	return i.Day != 0 && !i.Pinned()
+}
+
+// DoneOn returns true if the task is completed for the given day. Rolling
+// tasks are completed per ISO week, all other tasks once and for all.
+func (i Task) DoneOn(day time.Time) bool {
+	if !i.Rolling() {
+		return i.Done
+	}
+	return slices.Contains(i.DoneWeeks, i.doneKey(day))
+}
+
+// SetDoneOn marks the task as done or not done for the given day and updates
+// Done accordingly.
+func (i *Task) SetDoneOn(day time.Time, done bool) {
+	i.Done = done
+	if !i.Rolling() {
+		return
+	}
+	key := i.doneKey(day)
+	weeks := slices.DeleteFunc(slices.Clone(i.DoneWeeks), func(w string) bool {
+		return w == key
+	})
+	if done {
+		weeks = append(weeks, key)
+	}
+	if len(weeks) == 0 {
+		weeks = nil
+	}
+	i.DoneWeeks = weeks
+}
+
+// Check marks the task as done or not done for the given day like SetDoneOn.
+// Checking records now as the time the task was completed.
+func (i *Task) Check(day time.Time, done bool, now time.Time) {
+	i.SetDoneOn(day, done)
+	switch {
+	case done:
+		i.Completed = now.Truncate(time.Second)
+	case len(i.DoneWeeks) == 0:
+		i.Completed = time.Time{}
+	}
+}
+
+// MultiDay returns true if the task recurs on several days of a week.
+func (i Task) MultiDay() bool {
+	return i.Recurrence != nil && i.Recurrence.Freq == FreqWeekdays
+}
+
+func (i Task) doneKey(day time.Time) string {
+	if i.MultiDay() {
+		return day.Format(DateFormat)
+	}
+	return WeekKey(day)
+}
+
+// MoveTo schedules the task for the given day, 0 for the Inbox, whose date
+// in the current week is date. Pinned tasks are pinned to the new date or
+// unpinned if moved to the Inbox. The done state is kept.
+func (i *Task) MoveTo(day int, date time.Time) {
+	done := i.Done
+	i.Day = day
+	if i.Pinned() {
+		if day == 0 {
+			i.Unpin()
+		} else {
+			i.Pin(date)
+		}
+	}
+	i.SetDoneOn(date, done)
+}
+
+// OccursOn returns true if the task is scheduled for the given day. Pinned
+// tasks occur on their date only, rolling tasks on every day that matches
+// their weekday and recurrence.
+func (i Task) OccursOn(day time.Time) bool {
+	if d, ok := i.PinnedDate(); ok {
+		return sameDate(d, day)
+	}
+	if i.Recurrence != nil {
+		return i.Recurrence.OccursOn(i.Day, day)
+	}
+	return i.Day == Weekday(day)
+}
+
+// WeekKey returns the ISO year and week of the given date, e.g. "2026-W07".
+func WeekKey(d time.Time) string {
+	year, week := d.ISOWeek()
+	return fmt.Sprintf("%04d-W%02d", year, week)
+}
+
+// Weekday returns the ISO weekday of the given date, 1 for Monday up to 7 for
+// Sunday.
+func Weekday(d time.Time) int {
+	wd := int(d.Weekday())
+	if wd == 0 {
+		return 7
+	}
+	return wd
+}
+
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
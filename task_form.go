@@ -27,13 +27,32 @@ func CreateTaskForm(task *Task, contexts []Context) *huh.Form {
 		Title("Context").
 		Key("context").
 		Options(options...)
+	var recurrenceOptions []huh.Option[string]
+	for _, o := range RecurrenceOptions {
+		recurrenceOptions = append(recurrenceOptions, huh.NewOption(o.Label, o.Rule))
+	}
+
+	recurrenceSelect := huh.NewSelect[string]().
+		Title("Repeat").
+		Key("recurrence").
+		Options(recurrenceOptions...).
+		Inline(true)
+
+	descText := huh.NewText().
+		Title("Notes").
+		Key("description").
+		Lines(3)
+
 	if task != nil {
 		titleInput = titleInput.Value(&task.Name)
+		descText = descText.Value(&task.Desc)
 		contextSelect = contextSelect.Value(&task.Context)
+		rule := task.Recurrence.String()
+		recurrenceSelect = recurrenceSelect.Value(&rule)
 	}
 
 	k := huh.NewDefaultKeyMap()
 	k.Quit = key.NewBinding(key.WithKeys("esc"), key.WithHelp("esc", "Cancel"))
-	return huh.NewForm(huh.NewGroup(titleInput), huh.NewGroup(contextSelect)).
-		WithLayout(huh.LayoutGrid(1, 2)).WithKeyMap(k)
+	return huh.NewForm(huh.NewGroup(titleInput), huh.NewGroup(descText), huh.NewGroup(contextSelect), huh.NewGroup(recurrenceSelect)).
+		WithLayout(huh.LayoutGrid(1, 4)).WithKeyMap(k)
 }
@@ -0,0 +1,265 @@
+// Package todotxt converts tasks from and to the todo.txt format, see
+// https://github.com/todotxt/todo.txt.
+package todotxt
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/week"
+)
+
+// Keys of the key:value extensions written and read.
+const (
+	keyDue  = "due"
+	keyDesc = "desc"
+	keyID   = "id"
+)
+
+var (
+	priority = regexp.MustCompile(`^\([A-Z]\)$`)
+	date     = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+)
+
+// contextEscaper escapes context names into a single word. Underscores stand
+// for spaces, so underscores themselves are percent-encoded.
+var contextEscaper = strings.NewReplacer("%", "%25", "_", "%5F", " ", "_")
+
+// Format returns the tasks shown in the week as todo.txt lines. Done tasks
+// start with x, contexts other than none become @context with spaces
+// replaced by underscores, weekdays become a due date in the week,
+// descriptions are escaped into a desc extension and the ID is kept in an id
+// extension. Words of the name that would be read as something else are
+// percent-encoded.
+func Format(w *week.Week, contexts []scheduled.Context) string {
+	names := make(map[int]string)
+	for _, c := range contexts {
+		if c.ID != scheduled.ContextNone.ID {
+			names[c.ID] = contextEscaper.Replace(c.Name)
+		}
+	}
+
+	var b strings.Builder
+	for _, i := range w.View() {
+		t := w.Task(i)
+		var fields []string
+		if t.Done {
+			fields = append(fields, "x")
+		}
+		fields = append(fields, escapeName(t.Name)...)
+		if name, ok := names[t.Context]; ok {
+			fields = append(fields, "@"+name)
+		}
+		if t.Day != 0 {
+			fields = append(fields, keyDue+":"+w.DateOf(t.Day).Format(scheduled.DateFormat))
+		}
+		if t.Desc != "" {
+			fields = append(fields, keyDesc+":"+url.PathEscape(t.Desc))
+		}
+		fields = append(fields, keyID+":"+url.PathEscape(t.ID))
+		b.WriteString(strings.Join(fields, " "))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// escapeName returns the words of the name with percent signs, @contexts and
+// our extensions percent-encoded. The first word is also encoded if it would
+// be read as done marker, priority or date.
+func escapeName(name string) []string {
+	words := strings.Fields(name)
+	for i, word := range words {
+		word = strings.ReplaceAll(word, "%", "%25")
+		key, _, ok := strings.Cut(word, ":")
+		switch {
+		case strings.HasPrefix(word, "@"):
+			word = "%40" + word[1:]
+		case ok && (key == keyDue || key == keyDesc || key == keyID):
+			word = strings.Replace(word, ":", "%3A", 1)
+		case i == 0 && (word == "x" || priority.MatchString(word) || date.MatchString(word)):
+			word = fmt.Sprintf("%%%02X", word[0]) + word[1:]
+		}
+		words[i] = word
+	}
+	return words
+}
+
+// unescape decodes a word encoded by Format. Words that are not validly
+// encoded, e.g. written by other programs, are returned as they are.
+func unescape(word string) string {
+	if s, err := url.PathUnescape(word); err == nil {
+		return s
+	}
+	return word
+}
+
+// Parse returns the tasks of the todo.txt lines for the week together with
+// the contexts, which include the contexts created for unknown @contexts.
+// Tasks due in the week are scheduled for the weekday, tasks due on other
+// dates are pinned and tasks without due date go to the Inbox. Done tasks are
+// completed on the completion date after the x, or else on their due date.
+// Priorities and creation dates are dropped. Tasks keep the ID of their id
+// extension.
+func Parse(text string, w *week.Week, contexts []scheduled.Context) ([]scheduled.Task, []scheduled.Context, error) {
+	contexts = append([]scheduled.Context(nil), contexts...)
+	var tasks []scheduled.Task
+	for n, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t, context, err := parseLine(line, w)
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if context != "" {
+			var c scheduled.Context
+			c, contexts = findOrAdd(contexts, context)
+			t.Context = c.ID
+		} else {
+			t.Context = scheduled.ContextNone.ID
+		}
+		tasks = append(tasks, t)
+	}
+	return tasks, contexts, nil
+}
+
+// parseLine parses a single line into a task and the name of its first
+// @context.
+func parseLine(line string, w *week.Week) (scheduled.Task, string, error) {
+	var t scheduled.Task
+	var completed time.Time
+	fields := strings.Fields(line)
+	if len(fields) > 0 && fields[0] == "x" {
+		t.Done = true
+		fields = fields[1:]
+		if len(fields) > 0 && date.MatchString(fields[0]) {
+			completed, _ = time.ParseInLocation(scheduled.DateFormat, fields[0], time.Local)
+			fields = fields[1:]
+		}
+	}
+	if len(fields) > 0 && priority.MatchString(fields[0]) {
+		fields = fields[1:]
+	}
+	if len(fields) > 0 && date.MatchString(fields[0]) {
+		fields = fields[1:]
+	}
+
+	var words []string
+	var context string
+	var due time.Time
+	for _, f := range fields {
+		key, value, _ := strings.Cut(f, ":")
+		switch {
+		case strings.HasPrefix(f, "@") && len(f) > 1 && context == "":
+			context = unescape(strings.ReplaceAll(f[1:], "_", " "))
+		case key == keyDue && value != "":
+			d, err := time.ParseInLocation(scheduled.DateFormat, value, time.Local)
+			if err != nil {
+				return t, "", fmt.Errorf("invalid due date '%s'", value)
+			}
+			due = d
+		case key == keyDesc && value != "":
+			desc, err := url.PathUnescape(value)
+			if err != nil {
+				return t, "", fmt.Errorf("invalid description '%s'", value)
+			}
+			t.Desc = desc
+		case key == keyID && value != "":
+			t.ID = unescape(value)
+		default:
+			words = append(words, unescape(f))
+		}
+	}
+	t.Name = strings.Join(words, " ")
+	if t.Name == "" {
+		return t, "", errors.New("task has no name")
+	}
+
+	if !due.IsZero() {
+		t.Day = scheduled.Weekday(due)
+		if !w.DateOf(t.Day).Equal(due) {
+			t.Pin(due)
+		}
+		t.SetDoneOn(due, t.Done)
+		if t.Rolling() {
+			t.Done = false
+		}
+	}
+	if completed.IsZero() {
+		completed = due
+	}
+	if done(t) {
+		t.Completed = completed
+	}
+	return t, context, nil
+}
+
+// Import adds the parsed tasks to the week. Tasks with the ID of a task in the
+// week update its name, description, context, day and done state instead, so
+// that importing an export again doesn't duplicate tasks. It returns the
+// number of updated tasks. Done tasks without completion date are completed
+// now.
+func Import(w *week.Week, tasks []scheduled.Task) int {
+	updated := 0
+	for _, t := range tasks {
+		i := slices.IndexFunc(w.Tasks, func(e scheduled.Task) bool {
+			return t.ID != "" && e.ID == t.ID
+		})
+		if i < 0 {
+			w.Add(t)
+			if done(t) && t.Completed.IsZero() {
+				w.SetDone(len(w.Tasks)-1, true)
+			}
+			continue
+		}
+		update(w, i, t)
+		updated++
+	}
+	return updated
+}
+
+// update copies the imported task to the task at index i of the week.
+// Recurrences are kept unless the task is pinned to another week.
+func update(w *week.Week, i int, t scheduled.Task) {
+	e := &w.Tasks[i]
+	e.Name, e.Desc, e.Context = t.Name, t.Desc, t.Context
+	switch {
+	case t.Pinned():
+		e.Day, e.Date, e.Recurrence = t.Day, t.Date, nil
+	case w.Task(i).Day != t.Day:
+		// Tasks recurring on several days stay on their days
+		_ = w.Move(i, t.Day)
+	}
+	if w.Task(i).Done != done(t) {
+		w.SetDone(i, done(t))
+		if !t.Completed.IsZero() {
+			e.Completed = t.Completed
+		}
+	}
+}
+
+// done returns true if the parsed task is done, either once and for all or
+// in the week of its due date.
+func done(t scheduled.Task) bool {
+	return t.Done || len(t.DoneWeeks) > 0
+}
+
+// findOrAdd returns the context with the given name, ignoring case, and adds
+// it to the contexts if it does not exist.
+func findOrAdd(contexts []scheduled.Context, name string) (scheduled.Context, []scheduled.Context) {
+	maxID := scheduled.ContextNone.ID
+	for _, c := range contexts {
+		if strings.EqualFold(c.Name, name) {
+			return c, contexts
+		}
+		maxID = max(maxID, c.ID)
+	}
+	c := scheduled.Context{ID: maxID + 1, Name: name}
+	return c, append(contexts, c)
+}
@@ -0,0 +1,146 @@
+package todotxt
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/week"
+)
+
+// wednesday is in week 10 of 2026, which starts on Monday, March 2.
+var wednesday = time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local)
+
+var contexts = []scheduled.Context{scheduled.ContextNone, {ID: 2, Name: "deep work"}}
+
+func TestFormat(t *testing.T) {
+	w := week.New([]scheduled.Task{
+		{ID: "1", Name: "Buy milk", Context: 1},
+		{ID: "2", Name: "Write report", Day: 2, Context: 2, Desc: "Chapter 1\nand 2", DoneWeeks: []string{"2026-W10"}},
+		{ID: "3", Name: "Tax office", Day: 5, Date: "2026-03-06", Done: true},
+	}, wednesday)
+
+	want := "Buy milk id:1\n" +
+		"x Write report @deep_work due:2026-03-03 desc:Chapter%201%0Aand%202 id:2\n" +
+		"x Tax office due:2026-03-06 id:3\n"
+	if got := Format(w, contexts); got != want {
+		t.Errorf("Format() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    scheduled.Task
+		context string
+	}{
+		{"Buy milk", scheduled.Task{Name: "Buy milk", Context: 1}, ""},
+		{"(A) 2026-03-01 Call Anna +family", scheduled.Task{Name: "Call Anna +family", Context: 1}, ""},
+		{"x 2026-03-04 2026-03-01 Write report @Deep_Work due:2026-03-03 desc:Chapter%201%0Aand%202",
+			scheduled.Task{Name: "Write report", Day: 2, Context: 2, Desc: "Chapter 1\nand 2", DoneWeeks: []string{"2026-W10"}, Completed: time.Date(2026, time.March, 4, 0, 0, 0, 0, time.Local)}, ""},
+		{"x Tax office due:2026-04-14", scheduled.Task{Name: "Tax office", Day: 2, Date: "2026-04-14", Done: true, Context: 1, Completed: time.Date(2026, time.April, 14, 0, 0, 0, 0, time.Local)}, ""},
+		{"Dentist due:2026-04-14", scheduled.Task{Name: "Dentist", Day: 2, Date: "2026-04-14", Context: 1}, ""},
+		{"Plan trip @travel", scheduled.Task{Name: "Plan trip", Context: 3}, "travel"},
+		{"Pay 50% now id:42", scheduled.Task{ID: "42", Name: "Pay 50% now", Context: 1}, ""},
+		{"%78-ray %40home due%3Asoon @a%5Fb_c", scheduled.Task{Name: "x-ray @home due:soon", Context: 3}, "a_b c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			tasks, got, err := Parse(tt.line, week.New(nil, wednesday), contexts)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if len(tasks) != 1 || !reflect.DeepEqual(tasks[0], tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", tasks, tt.want)
+			}
+			if tt.context != "" && got[len(got)-1].Name != tt.context {
+				t.Errorf("Parse() contexts = %v, want %s to be added", got, tt.context)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Buy milk\nx due:2026-03-04", "line 2: task has no name"},
+		{"Report due:tomorrow", "line 1: invalid due date 'tomorrow'"},
+	}
+	for _, tt := range tests {
+		if _, _, err := Parse(tt.text, week.New(nil, wednesday), contexts); err == nil || err.Error() != tt.want {
+			t.Errorf("Parse(%q) error = %v, want %s", tt.text, err, tt.want)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	contexts := append(contexts, scheduled.Context{ID: 3, Name: "snake_case"}, scheduled.Context{ID: 4, Name: "100% focus"})
+	tasks := []scheduled.Task{
+		{Name: "Inbox", Context: 1},
+		{Name: "Friday", Day: 5, Context: 2, Desc: "50% done: see https://example.com/a b"},
+		{Name: "x marks the spot", Context: 3},
+		{Name: "(A) is not a priority", Day: 1, Context: 4},
+		{Name: "2026-03-01 retrospective", Context: 1},
+		{Name: "Read due:soon and desc:later id:none", Context: 1},
+		{Name: "Call @home about 100%25", Context: 1},
+	}
+	w := week.New(nil, wednesday)
+	for _, task := range tasks {
+		w.Add(task)
+	}
+
+	parsed, got, err := Parse(Format(w, contexts), week.New(nil, wednesday), contexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(contexts) {
+		t.Errorf("contexts = %v, want no new contexts", got)
+	}
+	if len(parsed) != len(tasks) {
+		t.Fatalf("Parse() = %+v, want %d tasks", parsed, len(tasks))
+	}
+	byID := make(map[string]scheduled.Task)
+	for _, p := range parsed {
+		byID[p.ID] = p
+	}
+	for _, want := range w.Tasks {
+		p := byID[want.ID]
+		if p.ID != want.ID || p.Name != want.Name || p.Day != want.Day || p.Desc != want.Desc || p.Context != want.Context || p.Done {
+			t.Errorf("round trip = %+v, want %+v", p, want)
+		}
+	}
+}
+
+func TestImport(t *testing.T) {
+	w := week.New([]scheduled.Task{
+		{ID: "1", Name: "Rolling", Day: 1, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekly, Interval: 2, Start: "2026-03-02"}},
+		{ID: "2", Name: "Inbox", Context: 2},
+	}, wednesday)
+	tasks, _, err := Parse("x Rolling renamed due:2026-03-02 id:1\n"+
+		"Inbox due:2026-03-20 id:2\n"+
+		"x New id:3\n"+
+		"Without ID\n", w, contexts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if updated := Import(w, tasks); updated != 2 {
+		t.Errorf("Import() = %d, want 2 updated tasks", updated)
+	}
+	if len(w.Tasks) != 4 || w.Tasks[2].ID != "3" || w.Tasks[3].ID == "" {
+		t.Fatalf("tasks = %+v, want 2 new tasks with IDs", w.Tasks)
+	}
+	if got := w.Tasks[2]; !got.Done || !got.Completed.Equal(wednesday) {
+		t.Errorf("Tasks[2] = %+v, want it completed now", got)
+	}
+	if got := w.Task(0); got.Name != "Rolling renamed" || !got.Done || got.Recurrence == nil {
+		t.Errorf("Task(0) = %+v, want renamed and done, keeping its recurrence", got)
+	}
+	if got := w.Tasks[1]; got.Date != "2026-03-20" || got.Day != 5 || got.Context != 1 {
+		t.Errorf("Tasks[1] = %+v, want pinned to 2026-03-20 without context", got)
+	}
+}
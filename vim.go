@@ -0,0 +1,223 @@
+package scheduled
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// VimKeys is an alternative key map with vim style bindings. Bindings made of
+// several keys, like "d d", are separated by spaces and completed by a
+// Sequence. Digits are not bound, because they are typed as counts.
+var VimKeys = KeyMap{
+	NextDay: key.NewBinding(
+		key.WithKeys("l"),
+		key.WithHelp("l", "next day"),
+	),
+	PrevDay: key.NewBinding(
+		key.WithKeys("h"),
+		key.WithHelp("h", "prev day"),
+	),
+	Esc: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "focus inbox"),
+	),
+	New: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "new task"),
+	),
+	Enter: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "edit task"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("d d"),
+		key.WithHelp("dd", "delete task"),
+	),
+	Space: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "check / uncheck task"),
+	),
+	ShiftRight: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "move task right"),
+	),
+	ShiftLeft: key.NewBinding(
+		key.WithKeys("H"),
+		key.WithHelp("H", "move task left"),
+	),
+	ShiftUp: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "move task up"),
+	),
+	ShiftDown: key.NewBinding(
+		key.WithKeys("J"),
+		key.WithHelp("J", "move task down"),
+	),
+	Left: key.NewBinding(
+		key.WithKeys("[", "left"),
+		key.WithHelp("[", "prev week"),
+	),
+	Right: key.NewBinding(
+		key.WithKeys("]", "right"),
+		key.WithHelp("]", "next week"),
+	),
+	Help: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "toggle help"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("q"),
+		key.WithHelp("q", "quit"),
+	),
+	Num: key.NewBinding(
+		key.WithDisabled(),
+	),
+	MoveToToday: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "move task to today"),
+	),
+	MoveToInbox: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "move task inbox"),
+	),
+	Contexts: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "show contexts"),
+	),
+	CopyTasks: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy tasks"),
+	),
+	CopyWeek: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy week"),
+	),
+	Pin: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "pin / unpin task"),
+	),
+	Details: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "toggle details"),
+	),
+	Descriptions: key.NewBinding(
+		key.WithKeys("Z"),
+		key.WithHelp("Z", "toggle notes in lists"),
+	),
+	Undo: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "undo"),
+	),
+	Redo: key.NewBinding(
+		key.WithKeys("ctrl+r"),
+		key.WithHelp("ctrl+r", "redo"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("g g"),
+		key.WithHelp("gg", "first task"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "last task"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search tasks"),
+	),
+	Palette: key.NewBinding(
+		key.WithKeys(":", "ctrl+p"),
+		key.WithHelp(":", "command palette"),
+	),
+	Mark: key.NewBinding(
+		key.WithKeys("v"),
+		key.WithHelp("v", "mark / unmark task"),
+	),
+	Archive: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "show archive"),
+	),
+	Report: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "copy week report"),
+	),
+}
+
+// Sequence is a state machine that collects the keys of multi key bindings
+// like "d d" and counts like the 3 in 3L, since key.Binding only matches
+// single keys.
+type Sequence struct {
+	keys    []string
+	count   int
+	pending []string
+}
+
+// NewSequence creates a sequence for the enabled bindings of the key map.
+func NewSequence(k KeyMap) *Sequence {
+	s := &Sequence{}
+	for _, b := range bindings(&k) {
+		if b.binding.Enabled() {
+			s.keys = append(s.keys, b.binding.Keys()...)
+		}
+	}
+	return s
+}
+
+// Feed adds the key to the sequence. Once a binding is complete, it returns a
+// key message matching the binding, the count typed before or 1, and true.
+// Keys starting no binding complete immediately, while unknown sequences are
+// dropped.
+func (s *Sequence) Feed(msg tea.KeyMsg) (tea.KeyMsg, int, bool) {
+	k := msg.String()
+	if len(s.pending) == 0 && s.isCount(k) {
+		s.count = s.count*10 + int(k[0]-'0')
+		return msg, 0, false
+	}
+
+	typed := strings.Join(append(s.pending, k), " ")
+	if s.isPrefix(typed) {
+		s.pending = append(s.pending, k)
+		return msg, 0, false
+	}
+
+	count := max(s.count, 1)
+	sequence := len(s.pending) > 0
+	s.Reset()
+	if !sequence {
+		return msg, count, true
+	}
+	if !s.isBound(typed) {
+		return msg, 0, false
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(typed)}, count, true
+}
+
+// Reset discards the keys typed so far.
+func (s *Sequence) Reset() {
+	s.count = 0
+	s.pending = nil
+}
+
+// isCount returns true if k continues a count. Zero only continues counts, as
+// in 10.
+func (s *Sequence) isCount(k string) bool {
+	if len(k) != 1 || k[0] < '0' || k[0] > '9' || s.isBound(k) {
+		return false
+	}
+	return k != "0" || s.count > 0
+}
+
+func (s *Sequence) isBound(k string) bool {
+	return slices.Contains(s.keys, k)
+}
+
+func (s *Sequence) isPrefix(typed string) bool {
+	for _, bound := range s.keys {
+		if strings.HasPrefix(bound, typed+" ") {
+			return true
+		}
+	}
+	return false
+}
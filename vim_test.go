@@ -0,0 +1,63 @@
+package scheduled
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func keyMsg(k string) tea.KeyMsg {
+	switch k {
+	case "esc":
+		return tea.KeyMsg{Type: tea.KeyEsc}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+}
+
+func TestSequence_Feed(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []string
+		want  string
+		count int
+	}{
+		{"single key", []string{"x"}, "x", 1},
+		{"sequence", []string{"d", "d"}, "d d", 1},
+		{"count", []string{"3", "L"}, "L", 3},
+		{"count with zero", []string{"1", "0", "j"}, "j", 10},
+		{"count before sequence", []string{"2", "d", "d"}, "d d", 2},
+		{"unbound key", []string{"j"}, "j", 1},
+		{"zero without count", []string{"0"}, "0", 1},
+		{"unknown sequence is dropped", []string{"d", "x", "G"}, "G", 1},
+		{"esc cancels count", []string{"3", "d", "esc", "x"}, "x", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSequence(VimKeys)
+			var got tea.KeyMsg
+			var count int
+			var complete bool
+			for i, k := range tt.keys {
+				got, count, complete = s.Feed(keyMsg(k))
+				if i < len(tt.keys)-1 && complete && k != "esc" {
+					t.Fatalf("sequence completed early at %q", k)
+				}
+			}
+			if !complete {
+				t.Fatal("sequence did not complete")
+			}
+			if got.String() != tt.want || count != tt.count {
+				t.Errorf("Feed() = %q, %d, want %q, %d", got.String(), count, tt.want, tt.count)
+			}
+		})
+	}
+}
+
+func TestVimKeys_Valid(t *testing.T) {
+	if err := ValidateKeys(VimKeys, ContextViewKeys); err != nil {
+		t.Errorf("ValidateKeys() error = %v", err)
+	}
+}
@@ -0,0 +1,174 @@
+package week
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rwirdemann/scheduled"
+	"github.com/rwirdemann/scheduled/date"
+)
+
+// Week gives access to tasks as they are scheduled in the calendar week of a
+// given day. Tasks are kept in the form they are stored in, i.e. without the
+// done flag of rolling tasks, which only reflects a single week.
+type Week struct {
+	Tasks  []scheduled.Task
+	monday time.Time
+	now    time.Time
+}
+
+// New creates a week for the given tasks and the week of now.
+func New(tasks []scheduled.Task, now time.Time) *Week {
+	year, week := now.ISOWeek()
+	return &Week{Tasks: tasks, monday: date.GetMondayOfWeek(year, week), now: now}
+}
+
+// DateOf returns the date of the given day, 1 for Monday up to 7 for Sunday.
+func (w *Week) DateOf(day int) time.Time {
+	return w.monday.AddDate(0, 0, day-1)
+}
+
+// doneDate returns the date the done state of the task is shown for. Tasks
+// recurring on several days are shown for today.
+func (w *Week) doneDate(t scheduled.Task) time.Time {
+	if t.MultiDay() {
+		return w.now
+	}
+	return w.DateOf(t.Day)
+}
+
+// Task returns the task at index i as it is shown in the week, with the day of
+// pinned tasks and the done state for the week.
+func (w *Week) Task(i int) scheduled.Task {
+	t := w.Tasks[i]
+	if d, ok := t.PinnedDate(); ok {
+		t.Day = scheduled.Weekday(d)
+	}
+	t.Done = t.DoneOn(w.doneDate(t))
+	return t
+}
+
+// Days returns the days the task is shown on in the week, 0 for the Inbox.
+// The result is empty if the task is not scheduled for the week.
+func (w *Week) Days(t scheduled.Task) []int {
+	if t.Day == 0 && !t.Pinned() {
+		return []int{0}
+	}
+	var days []int
+	for day := 1; day <= 7; day++ {
+		if t.OccursOn(w.DateOf(day)) {
+			days = append(days, day)
+		}
+	}
+	return days
+}
+
+// View returns the indexes of the tasks shown in the week in the order they
+// are shown on the board.
+func (w *Week) View() []int {
+	var indexes []int
+	for i := range w.Tasks {
+		if len(w.Days(w.Tasks[i])) > 0 {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		ta, tb := w.Task(indexes[a]), w.Task(indexes[b])
+		if ta.Day != tb.Day {
+			return ta.Day < tb.Day
+		}
+		return ta.Pos < tb.Pos
+	})
+	return indexes
+}
+
+// Find returns the index of the task addressed by ref, which is either a
+// position in View, starting at 1, or a prefix of the task ID.
+func (w *Week) Find(ref string) (int, error) {
+	view := w.View()
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(view) {
+		return view[n-1], nil
+	}
+
+	found := -1
+	for i, t := range w.Tasks {
+		if ref != "" && strings.HasPrefix(t.ID, ref) {
+			if found >= 0 {
+				return -1, fmt.Errorf("task '%s' is ambiguous", ref)
+			}
+			found = i
+		}
+	}
+	if found < 0 {
+		return -1, fmt.Errorf("task '%s' not found", ref)
+	}
+	return found, nil
+}
+
+// Add appends the task to the end of its day and returns it. Tasks without ID
+// get a new one.
+func (w *Week) Add(t scheduled.Task) scheduled.Task {
+	if t.ID == "" {
+		t.ID = uuid.NewString()
+	}
+	t.Pos = w.nextPos(t.Day, t.ID)
+	w.Tasks = append(w.Tasks, t)
+	return t
+}
+
+// SetDone marks the task at index i as done or not done in the week. Tasks
+// marked as done are completed now.
+func (w *Week) SetDone(i int, done bool) {
+	t := &w.Tasks[i]
+	t.Check(w.doneDate(*t), done, w.now)
+	if t.Rolling() {
+		t.Done = false
+	}
+}
+
+// Move moves the task at index i to the end of the given day, 0 for the Inbox.
+// Tasks recurring on several days can't be moved.
+func (w *Week) Move(i, day int) error {
+	t := w.Task(i)
+	if t.MultiDay() {
+		return fmt.Errorf("'%s' recurs on several days and can't be moved", t.Name)
+	}
+	t.MoveTo(day, w.DateOf(day))
+	t.Pos = w.nextPos(day, t.ID)
+	if t.Rolling() {
+		t.Done = false
+	}
+	w.Tasks[i] = t
+	return nil
+}
+
+// Remove removes the task at index i.
+func (w *Week) Remove(i int) {
+	w.Tasks = append(w.Tasks[:i], w.Tasks[i+1:]...)
+}
+
+func (w *Week) nextPos(day int, id string) int {
+	pos := 0
+	for _, t := range w.Tasks {
+		if t.ID != id && t.Day == day && t.Pos >= pos {
+			pos = t.Pos + 1
+		}
+	}
+	return pos
+}
+
+// Anchor returns a copy of the given recurrence that starts in the week.
+func (w *Week) Anchor(r *scheduled.Recurrence) *scheduled.Recurrence {
+	if r == nil {
+		return nil
+	}
+	anchored := *r
+	if anchored.Freq == scheduled.FreqWeekly {
+		anchored.Start = w.monday.Format(scheduled.DateFormat)
+	}
+	return &anchored
+}
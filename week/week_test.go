@@ -0,0 +1,85 @@
+package week
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rwirdemann/scheduled"
+)
+
+func TestWeek_View(t *testing.T) {
+	now := time.Now()
+	w := New([]scheduled.Task{
+		{ID: "b", Name: "Second", Day: 2, Pos: 1},
+		{ID: "pinned", Name: "Other week", Day: 1, Date: now.AddDate(0, 0, 14).Format(scheduled.DateFormat)},
+		{ID: "a", Name: "First", Day: 2, Pos: 0},
+		{ID: "inbox", Name: "Inbox"},
+	}, now)
+
+	var ids []string
+	for _, i := range w.View() {
+		ids = append(ids, w.Tasks[i].ID)
+	}
+	want := []string{"inbox", "a", "b"}
+	if len(ids) != len(want) {
+		t.Fatalf("View() = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Errorf("View()[%d] = %s, want %s", i, ids[i], want[i])
+		}
+	}
+}
+
+func TestWeek_SetDoneAndMove(t *testing.T) {
+	now := time.Now()
+	w := New([]scheduled.Task{{ID: "a", Name: "Task", Day: 1}}, now)
+
+	w.SetDone(0, true)
+	if w.Tasks[0].Done {
+		t.Error("Stored rolling task should not carry the done flag")
+	}
+	if !w.Tasks[0].Completed.Equal(now.Truncate(time.Second)) {
+		t.Errorf("Completed = %v, want %v", w.Tasks[0].Completed, now)
+	}
+	if !w.Task(0).Done {
+		t.Error("Task should be done in the week")
+	}
+
+	if err := w.Move(0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := w.Task(0); got.Day != 3 || !got.Done {
+		t.Errorf("Task(0) = %+v, want done on Wednesday", got)
+	}
+
+	w.Tasks[0].Recurrence = &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}
+	if err := w.Move(0, 4); err == nil {
+		t.Error("Moving a task that recurs on several days should fail")
+	}
+}
+
+func TestWeek_Days(t *testing.T) {
+	// March 4, 2026 is in the week of Monday, March 2
+	w := New(nil, time.Date(2026, time.March, 4, 9, 0, 0, 0, time.Local))
+
+	tests := []struct {
+		name string
+		task scheduled.Task
+		want []int
+	}{
+		{"inbox", scheduled.Task{}, []int{0}},
+		{"rolling", scheduled.Task{Day: 3}, []int{3}},
+		{"pinned", scheduled.Task{Day: 5, Date: "2026-03-06"}, []int{5}},
+		{"pinned to another week", scheduled.Task{Day: 5, Date: "2026-03-13"}, nil},
+		{"weekdays", scheduled.Task{Day: 1, Recurrence: &scheduled.Recurrence{Freq: scheduled.FreqWeekdays}}, []int{1, 2, 3, 4, 5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.Days(tt.task); !slices.Equal(got, tt.want) {
+				t.Errorf("Days() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}